@@ -4,13 +4,14 @@ package gojs
 // #cgo pkg-config: javascriptcoregtk-3.0
 // #include <JavaScriptCore/JSBase.h>
 import "C"
-import (
-	"log"
-	"unsafe"
-)
+import "unsafe"
 
 // EvaluateScript evaluates the JavaScript code in script.
 func (ctx *Context) EvaluateScript(script string, thisObject *Object, sourceURL string, startingLineNumber int) (*Value, error) {
+	if ctx.Terminated() {
+		return nil, ErrTerminated
+	}
+
 	scriptRef := NewString(script)
 	defer scriptRef.Release()
 
@@ -22,9 +23,11 @@ func (ctx *Context) EvaluateScript(script string, thisObject *Object, sourceURL
 
 	if thisObject == nil {
 		thisObject = ctx.NewEmptyObject()
+	} else if err := ctx.adoptObject("EvaluateScript", thisObject); err != nil {
+		return nil, err
 	}
 
-	log.Println("About to evaluate script:", script, thisObject, sourceURL, startingLineNumber)
+	ctx.trace(TraceEval, "evaluating script", "sourceURL", sourceURL, "line", startingLineNumber, "bytes", len(script))
 
 	errVal := ctx.newErrorValue()
 	ret := C.JSEvaluateScript(ctx.ref,
@@ -33,6 +36,7 @@ func (ctx *Context) EvaluateScript(script string, thisObject *Object, sourceURL
 	if ret == nil {
 		// An error occurred
 		// Error information should be stored in exception
+		ctx.trace(TraceEval, "script raised an exception", "sourceURL", sourceURL, "error", errVal)
 		return nil, errVal
 	}
 
@@ -67,5 +71,6 @@ func (ctx *Context) CheckScriptSyntax(script string, sourceURL string, startingL
 
 // GarbageCollect performs a JavaScript garbage collection.
 func (ctx *Context) GarbageCollect() {
+	ctx.trace(TraceGC, "garbage collection requested", "nativeObjects", len(objects))
 	C.JSGarbageCollect(ctx.ref)
 }
@@ -86,3 +86,25 @@ func TestGarbageCollect(t *testing.T) {
 
 	ctx.GarbageCollect()
 }
+
+func TestEvaluateScriptException(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	_, err := ctx.EvaluateScript("throw new TypeError('bad type')", nil, "./testing.go", 1)
+	if err == nil {
+		t.Fatalf("ctx.EvaluateScript did not return the thrown exception")
+	}
+	exc, ok := err.(Exception)
+	if !ok {
+		t.Fatalf("ctx.EvaluateScript returned %T, which is not an Exception", err)
+	}
+	obj := ctx.ToObjectOrDie(exc.Value())
+	name, err := ctx.GetProperty(obj, "name")
+	if err != nil || ctx.ToStringOrDie(name) != "TypeError" {
+		t.Errorf("exception value is not the thrown TypeError (%v)", exc.Value())
+	}
+	if err := exc.Error(); err != "TypeError: bad type" {
+		t.Errorf("want exception message %q, got %q", "TypeError: bad type", err)
+	}
+}
@@ -0,0 +1,105 @@
+package gojs
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// maxSafeInteger is Number.MAX_SAFE_INTEGER, the largest integer n such
+// that every integer up to n is exactly representable as a number.
+const maxSafeInteger = 1<<53 - 1
+
+func (v *Value) IsBigInt() bool {
+	return v.Type() == TypeBigInt
+}
+
+// newBigIntHelper converts a decimal string to a BigInt.
+const newBigIntHelper = "(function (BigInt) { return function (s) { return BigInt(s); }; })(BigInt)"
+
+// NewBigInt creates a BigInt with the value x. It fails if the
+// JavaScriptCore in use does not support BigInt.
+func (ctx *Context) NewBigInt(x *big.Int) (*Value, error) {
+	return ctx.callHelper(newBigIntHelper, ctx.NewStringValue(x.String()))
+}
+
+// ToBigInt converts v to an integer as BigInt(v) would. Numbers that are not
+// integers throw a RangeError, which is returned as the error.
+func (v *Value) ToBigInt() (*big.Int, error) {
+	ret, err := v.ctx.callHelper(`(function (BigInt, String) {
+		return function (v) { return String(BigInt(v)); };
+	})(BigInt, String)`, v)
+	if err != nil {
+		return nil, err
+	}
+	str, err := ret.ToString()
+	if err != nil {
+		return nil, err
+	}
+	x, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("gojs: cannot parse BigInt %q", str)
+	}
+	return x, nil
+}
+
+// Int64Conversion selects how 64-bit integers are converted to JavaScript:
+// int64 and uint64, and int, uint and uintptr on platforms where they are 64
+// bits wide. It is a field of ConvertOptions.
+type Int64Conversion int
+
+const (
+	// Int64AsNumber converts to numbers, failing with a *RangeError for
+	// values beyond Number.MAX_SAFE_INTEGER, which would lose precision. It
+	// is the default.
+	Int64AsNumber Int64Conversion = iota
+
+	// Int64AsBigInt converts every 64-bit integer to a BigInt.
+	Int64AsBigInt
+
+	// Int64AsString converts every 64-bit integer to a decimal string, as
+	// the ",string" option of encoding/json does.
+	Int64AsString
+)
+
+// intToJSValue converts a Go integer according to the context's
+// Int64Conversion.
+func (ctx *Context) intToJSValue(v reflect.Value) (*Value, error) {
+	num, str, as, err := intConversion(v, ctx.convertOptions().Int64)
+	if err != nil {
+		return nil, err
+	}
+	switch as {
+	case Int64AsBigInt:
+		return ctx.callHelper(newBigIntHelper, ctx.NewStringValue(str))
+	case Int64AsString:
+		return ctx.NewStringValue(str), nil
+	}
+	return ctx.NewNumberValue(num), nil
+}
+
+// intConversion applies the Int64Conversion conv to the Go integer v. It
+// returns the form v takes, with num set for Int64AsNumber and str, the
+// decimal digits, for the others.
+func intConversion(v reflect.Value, conv Int64Conversion) (num float64, str string, as Int64Conversion, err error) {
+	var exact bool
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		num, str = float64(n), strconv.FormatInt(n, 10)
+		exact = n >= -maxSafeInteger && n <= maxSafeInteger
+	default:
+		n := v.Uint()
+		num, str = float64(n), strconv.FormatUint(n, 10)
+		exact = n <= maxSafeInteger
+	}
+
+	if v.Type().Bits() == 64 && conv != Int64AsNumber {
+		return 0, str, conv, nil
+	}
+	if !exact {
+		return 0, "", 0, &RangeError{fmt.Sprintf("gojs: %s value %s is beyond Number.MAX_SAFE_INTEGER", v.Type(), str)}
+	}
+	return num, str, Int64AsNumber, nil
+}
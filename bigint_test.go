@@ -0,0 +1,218 @@
+package gojs
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hasGlobalFunction reports whether the JavaScriptCore in use defines the
+// global function name. Older versions lack BigInt and Symbol.
+func hasGlobalFunction(ctx *Context, name string) bool {
+	v, err := ctx.EvaluateScript("typeof "+name, nil, "", 1)
+	return err == nil && v.String() == "function"
+}
+
+// skipWithout skips the test where the global function name is missing.
+func skipWithout(t *testing.T, ctx *Context, name string) {
+	t.Helper()
+	if !hasGlobalFunction(ctx, name) {
+		t.Skipf("this JavaScriptCore has no %s", name)
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	skipWithout(t, ctx, "BigInt")
+
+	x, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	v, err := ctx.NewBigInt(x)
+	if err != nil {
+		t.Fatalf("ctx.NewBigInt failed: %v", err)
+	}
+	if !v.IsBigInt() || v.Type() != TypeBigInt {
+		t.Errorf("v.Type() = %d, want TypeBigInt", v.Type())
+	}
+	if v.IsSymbol() || v.IsObject() {
+		t.Errorf("BigInt reported as a symbol or object")
+	}
+
+	got, err := v.ToBigInt()
+	if err != nil || got.Cmp(x) != 0 {
+		t.Errorf("v.ToBigInt() = %v, %v, want %v", got, err, x)
+	}
+	goval, err := v.GoValue()
+	if err != nil || goval.(*big.Int).Cmp(x) != 0 {
+		t.Errorf("v.GoValue() = %v, %v, want %v", goval, err, x)
+	}
+	if s := v.Inspect(nil); s != x.String()+"n" {
+		t.Errorf("v.Inspect() = %q", s)
+	}
+
+	got, err = ctx.NewNumberValue(42).ToBigInt()
+	if err != nil || got.Int64() != 42 {
+		t.Errorf("ToBigInt of 42 = %v, %v", got, err)
+	}
+	if _, err := ctx.NewNumberValue(1.5).ToBigInt(); err == nil {
+		t.Errorf("ToBigInt of 1.5 succeeded")
+	}
+}
+
+func TestInt64Conversion(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	fn := ctx.NewFunctionWithNative(func() int64 { return 1<<60 + 1 })
+	if err := ctx.GlobalObject().Set("big", fn.ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		conv Int64Conversion
+		want string
+	}{
+		{Int64AsNumber, "RangeError"},
+		{Int64AsBigInt, "bigint 1152921504606846977"},
+		{Int64AsString, "string 1152921504606846977"},
+	}
+	bigints := hasGlobalFunction(ctx, "BigInt")
+	for _, test := range tests {
+		if test.conv == Int64AsBigInt && !bigints {
+			continue
+		}
+		ctx.SetConvertOptions(ConvertOptions{Int64: test.conv})
+		ret, err := ctx.EvaluateScript(`
+			try { var v = big(); typeof v + ' ' + String(v); }
+			catch (e) { e instanceof RangeError ? 'RangeError' : String(e); }`, nil, "", 1)
+		if err != nil {
+			t.Fatalf("ctx.EvaluateScript failed: %v", err)
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("int64 conversion %d = %q, want %q", test.conv, got, test.want)
+		}
+	}
+
+	// Small values and narrower types stay numbers.
+	ctx.SetConvertOptions(ConvertOptions{})
+	if v := ctx.NewValue(int64(-5)); !v.IsNumber() || v.ToNumberOrDie() != -5 {
+		t.Errorf("ctx.NewValue(int64(-5)) = %v", v)
+	}
+	if v := ctx.NewValue(uint16(65535)); !v.IsNumber() || v.ToNumberOrDie() != 65535 {
+		t.Errorf("ctx.NewValue(uint16(65535)) = %v", v)
+	}
+
+	// ConvertValue returns the error that NewValue panics with.
+	var rangeErr *RangeError
+	if _, err := ctx.ConvertValue(int64(1 << 60)); !errors.As(err, &rangeErr) {
+		t.Errorf("ctx.ConvertValue(int64(1 << 60)) returned %v, want a *RangeError", err)
+	}
+	if _, err := ctx.NewArrayFromSlice([]int64{1 << 60}); !errors.As(err, &rangeErr) {
+		t.Errorf("NewArrayFromSlice([]int64{1 << 60}) returned %v, want a *RangeError", err)
+	}
+
+	// int, uint and uintptr follow the policy where they are 64 bits wide.
+	if strconv.IntSize == 64 && bigints {
+		ctx.SetConvertOptions(ConvertOptions{Int64: Int64AsBigInt})
+		v, err := ctx.ConvertValue(uint(1 << 60))
+		if err != nil || !v.IsBigInt() {
+			t.Errorf("ctx.ConvertValue(uint(1 << 60)) = %v, %v, want a BigInt", v, err)
+		}
+		arr, err := ctx.NewArrayFromSlice([]int{1 << 60, 1})
+		if err != nil {
+			t.Fatalf("NewArrayFromSlice([]int{1 << 60, 1}) failed: %v", err)
+		}
+		item, err := arr.GetIndex(0)
+		if err != nil || !item.IsBigInt() || item.String() != "1152921504606846976" {
+			t.Errorf("NewArrayFromSlice([]int{1 << 60, 1})[0] = %v, %v", item, err)
+		}
+		ctx.SetConvertOptions(ConvertOptions{})
+	}
+}
+
+func TestDecodeBigInt(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	skipWithout(t, ctx, "BigInt")
+
+	v, err := ctx.EvaluateScript("({id: BigInt('1152921504606846977'), huge: BigInt('123456789012345678901234567890'), list: [BigInt(7)]})", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+
+	var dst struct {
+		ID   int64
+		Huge *big.Int
+		List []uint8
+	}
+	if err := v.Decode(&dst); err != nil {
+		t.Fatalf("v.Decode failed: %v", err)
+	}
+	if dst.ID != 1<<60+1 || dst.Huge.String() != "123456789012345678901234567890" || len(dst.List) != 1 || dst.List[0] != 7 {
+		t.Errorf("v.Decode = %+v", dst)
+	}
+
+	var small struct{ Huge int64 }
+	var decodeErr *DecodeError
+	if err := v.Decode(&small); !errors.As(err, &decodeErr) {
+		t.Errorf("decoding a BigInt beyond int64 returned %v, want a *DecodeError", err)
+	}
+
+	goval, err := v.GoValue()
+	if err != nil {
+		t.Fatalf("v.GoValue failed: %v", err)
+	}
+	if id, ok := goval.(map[string]interface{})["id"].(*big.Int); !ok || id.String() != "1152921504606846977" {
+		t.Errorf("v.GoValue()[\"id\"] = %#v, want a *big.Int", goval.(map[string]interface{})["id"])
+	}
+}
+
+func TestIntFieldRange(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := &struct {
+		Small int8
+		Port  uint16
+		ID    int64
+	}{}
+	if err := ctx.GlobalObject().Set("o", ctx.NewNativeObject(obj).ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"o.Small = 127", ""},
+		{"o.Small = 128", "RangeError"},
+		{"o.Small = -129", "RangeError"},
+		{"o.Port = 65536", "RangeError"},
+		{"o.Port = -1", "RangeError"},
+		{"o.Port = NaN", "RangeError"},
+		{"o.ID = BigInt('9007199254740993')", ""},
+		{"o.ID = BigInt('9223372036854775808')", "RangeError"},
+	}
+	bigints := hasGlobalFunction(ctx, "BigInt")
+	for _, test := range tests {
+		if strings.Contains(test.script, "BigInt") && !bigints {
+			continue
+		}
+		ret, err := ctx.EvaluateScript("try { "+test.script+"; '' } catch (e) { e.name }", nil, "", 1)
+		if err != nil {
+			t.Fatalf("ctx.EvaluateScript failed: %v", err)
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s threw %q, want %q", test.script, got, test.want)
+		}
+	}
+	wantID := int64(9007199254740993)
+	if !bigints {
+		wantID = 0
+	}
+	if obj.Small != 127 || obj.Port != 0 || obj.ID != wantID {
+		t.Errorf("fields = %+v", *obj)
+	}
+}
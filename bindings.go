@@ -0,0 +1,56 @@
+package gojs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// HostBinding installs Go-implemented globals, functions or objects into a
+// context.
+type HostBinding func(ctx *Context) error
+
+var (
+	bindingsMu sync.Mutex
+	bindings   = make(map[string]HostBinding)
+)
+
+// RegisterHostBinding makes binding available to InstallHostBinding under
+// name. It is meant to be called from init functions, including those of Go
+// plugins loaded by the gojs command. It panics if name is already taken or
+// binding is nil.
+func RegisterHostBinding(name string, binding HostBinding) {
+	bindingsMu.Lock()
+	defer bindingsMu.Unlock()
+	if binding == nil {
+		panic("gojs: RegisterHostBinding binding is nil")
+	}
+	if _, dup := bindings[name]; dup {
+		panic("gojs: RegisterHostBinding called twice for binding " + name)
+	}
+	bindings[name] = binding
+}
+
+// HostBindings returns the sorted names of the registered host bindings.
+func HostBindings() []string {
+	bindingsMu.Lock()
+	defer bindingsMu.Unlock()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InstallHostBinding runs the host binding registered under name on ctx.
+func (ctx *Context) InstallHostBinding(name string) error {
+	bindingsMu.Lock()
+	binding := bindings[name]
+	bindingsMu.Unlock()
+
+	if binding == nil {
+		return fmt.Errorf("gojs: unknown host binding %q", name)
+	}
+	return binding(ctx)
+}
@@ -0,0 +1,46 @@
+package gojs
+
+import (
+	"testing"
+)
+
+func TestHostBindings(t *testing.T) {
+	RegisterHostBinding("test.answer", func(ctx *Context) error {
+		return ctx.SetProperty(ctx.GlobalObject(), "answer", ctx.NewNumberValue(42), 0)
+	})
+
+	found := false
+	for _, name := range HostBindings() {
+		if name == "test.answer" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("HostBindings() does not list a registered binding: %v", HostBindings())
+	}
+
+	ctx := NewContext()
+	defer ctx.Release()
+
+	if err := ctx.InstallHostBinding("test.answer"); err != nil {
+		t.Fatalf("ctx.InstallHostBinding returned an error (%v)", err)
+	}
+	ret, err := ctx.EvaluateScript("answer", nil, "./bindings_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if ctx.ToNumberOrDie(ret) != 42 {
+		t.Errorf("host binding was not installed")
+	}
+
+	if err := ctx.InstallHostBinding("test.missing"); err == nil {
+		t.Errorf("ctx.InstallHostBinding did not fail for an unknown binding")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterHostBinding did not panic on a duplicate name")
+		}
+	}()
+	RegisterHostBinding("test.answer", func(ctx *Context) error { return nil })
+}
@@ -0,0 +1,508 @@
+#include <JavaScriptCore/JSObjectRef.h>
+#include <JavaScriptCore/JSStringRef.h>
+#include <JavaScriptCore/JSValueRef.h>
+#include <stdlib.h>
+#include <string.h>
+#include "bulk.h"
+
+//=========================================================
+// Bulk access, to convert whole arrays and objects in a
+// single call from Go.
+//---------------------------------------------------------
+
+// Fills out with count elements of obj from index start, stopping at the
+// first element whose getter throws.
+void gojs_array_values(JSContextRef ctx, JSObjectRef obj, unsigned start, JSValueRef* out, unsigned count, JSValueRef* exception)
+{
+	unsigned lp;
+	for ( lp=0; lp<count; ++lp ) {
+		out[lp] = JSObjectGetPropertyAtIndex( ctx, obj, start+lp, exception );
+		if ( *exception ) {
+			return;
+		}
+	}
+}
+
+// Returns the enumerable property names of obj as consecutive NUL-terminated
+// UTF-8 strings in a buffer allocated with malloc. count is set to the number
+// of names and lengths to a malloc'ed array of their lengths in bytes, not
+// counting the terminator, since names may themselves contain NUL.
+char* gojs_property_names(JSContextRef ctx, JSObjectRef obj, size_t* count, size_t** lengths)
+{
+	JSPropertyNameArrayRef names = JSObjectCopyPropertyNames( ctx, obj );
+	size_t n = JSPropertyNameArrayGetCount( names );
+	size_t capacity = 1;
+	size_t lp;
+	for ( lp=0; lp<n; ++lp ) {
+		capacity += JSStringGetMaximumUTF8CStringSize( JSPropertyNameArrayGetNameAtIndex( names, lp ) );
+	}
+
+	char* buf = malloc( capacity );
+	size_t* lens = malloc( (n ? n : 1) * sizeof(size_t) );
+	if ( !buf || !lens ) {
+		free( buf );
+		free( lens );
+		JSPropertyNameArrayRelease( names );
+		*count = 0;
+		*lengths = NULL;
+		return NULL;
+	}
+
+	size_t used = 0;
+	for ( lp=0; lp<n; ++lp ) {
+		size_t written = JSStringGetUTF8CString( JSPropertyNameArrayGetNameAtIndex( names, lp ), buf+used, capacity-used );
+		lens[lp] = written ? written-1 : 0;
+		used += written;
+	}
+	JSPropertyNameArrayRelease( names );
+
+	*count = n;
+	*lengths = lens;
+	return buf;
+}
+
+//=========================================================
+// Flattening, to convert a tree of values in one call
+//---------------------------------------------------------
+
+typedef struct {
+	JSContextRef ctx;
+	const gojs_builtins* builtins;
+	JSStringRef length;
+
+	gojs_node* nodes;
+	size_t nodeCap, nodeCount;
+	JSChar* chars;
+	size_t charCap, charCount;
+	int nomem;
+
+	JSObjectRef parents[GOJS_MAX_DEPTH];
+	int depth;
+	JSValueRef* exception;
+} flattener;
+
+// Grows the malloc'ed buffer *buf of *cap items of size bytes to hold at
+// least need items. Returns 0 if memory ran out, leaving *buf unchanged.
+static int flatten_grow(void** buf, size_t* cap, size_t need, size_t size)
+{
+	if ( need <= *cap ) {
+		return 1;
+	}
+	size_t n = *cap ? *cap : 64;
+	while ( n < need ) {
+		n *= 2;
+	}
+	void* p = realloc( *buf, n*size );
+	if ( !p ) {
+		return 0;
+	}
+	*buf = p;
+	*cap = n;
+	return 1;
+}
+
+// Appends a node and returns its index. If memory runs out, nomem is set
+// and the node is dropped.
+static size_t flatten_node(flattener* f, int kind, double number, size_t len)
+{
+	size_t index = f->nodeCount;
+	if ( !flatten_grow( (void**)&f->nodes, &f->nodeCap, index+1, sizeof(gojs_node) ) ) {
+		f->nomem = 1;
+		return index;
+	}
+	++f->nodeCount;
+	gojs_node* node = &f->nodes[index];
+	node->kind = kind;
+	node->number = number;
+	node->offset = 0;
+	node->len = len;
+	return index;
+}
+
+// Appends a string node, or a BigInt node holding the decimal string.
+static void flatten_string(flattener* f, int kind, JSStringRef str)
+{
+	size_t len = JSStringGetLength( str );
+	size_t index = flatten_node( f, kind, 0, len );
+	if ( f->nomem ) {
+		return;
+	}
+	f->nodes[index].offset = f->charCount;
+	if ( len == 0 ) {
+		return;
+	}
+	if ( !flatten_grow( (void**)&f->chars, &f->charCap, f->charCount+len, sizeof(JSChar) ) ) {
+		f->nomem = 1;
+		return;
+	}
+	memcpy( f->chars+f->charCount, JSStringGetCharactersPtr( str ), len*sizeof(JSChar) );
+	f->charCount += len;
+}
+
+// Returns the decimal string of value if it is a BigInt, or NULL. The
+// string must be released.
+static JSStringRef flatten_bigint(flattener* f, JSValueRef value)
+{
+	JSValueRef str = JSObjectCallAsFunction( f->ctx, f->builtins->bigint, NULL, 1, &value, NULL );
+	if ( !str || !JSValueIsString( f->ctx, str ) ) {
+		return NULL;
+	}
+	return JSValueToStringCopy( f->ctx, str, NULL );
+}
+
+// Reports whether value is a symbol. Versions of JavaScriptCore that predate
+// kJSTypeSymbol and kJSTypeBigInt report both as objects.
+static int flatten_is_symbol(flattener* f, JSValueRef value)
+{
+	switch ( JSValueGetType( f->ctx, value ) ) {
+	case kJSTypeUndefined:
+	case kJSTypeNull:
+	case kJSTypeBoolean:
+	case kJSTypeNumber:
+	case kJSTypeString:
+		return 0;
+	default:
+		break;
+	}
+	if ( JSValueIsObject( f->ctx, value ) ) {
+		return 0;
+	}
+	JSStringRef str = flatten_bigint( f, value );
+	if ( str ) {
+		JSStringRelease( str );
+		return 0;
+	}
+	return 1;
+}
+
+// Reports whether obj is an array, as Array.isArray does. Unlike instanceof,
+// this rejects objects that merely inherit from Array.prototype.
+static int flatten_is_array(flattener* f, JSObjectRef obj)
+{
+	JSValueRef arg = obj;
+	JSValueRef ret = JSObjectCallAsFunction( f->ctx, f->builtins->isArray, NULL, 1, &arg, NULL );
+	return ret && JSValueToBoolean( f->ctx, ret );
+}
+
+static int flatten_value(flattener* f, JSValueRef value, int inArray);
+
+static int flatten_object(flattener* f, JSObjectRef obj)
+{
+	int lp;
+	for ( lp=0; lp<f->depth; ++lp ) {
+		if ( JSValueIsStrictEqual( f->ctx, f->parents[lp], obj ) ) {
+			return GOJS_CYCLIC;
+		}
+	}
+	if ( f->depth == GOJS_MAX_DEPTH ) {
+		return GOJS_TOO_DEEP;
+	}
+	f->parents[f->depth++] = obj;
+
+	int ret = GOJS_OK;
+	int kind = GOJS_ARRAY;
+	JSObjectRef items = obj;
+	if ( JSValueIsInstanceOfConstructor( f->ctx, obj, f->builtins->map, NULL ) ) {
+		kind = GOJS_MAP;
+	} else if ( JSValueIsInstanceOfConstructor( f->ctx, obj, f->builtins->set, NULL ) ) {
+		kind = GOJS_SET;
+	} else if ( !flatten_is_array( f, obj ) ) {
+		kind = GOJS_OBJECT;
+	}
+	if ( kind == GOJS_MAP || kind == GOJS_SET ) {
+		JSValueRef arg = obj;
+		JSValueRef entries = JSObjectCallAsFunction( f->ctx, f->builtins->entries, NULL, 1, &arg, f->exception );
+		if ( *f->exception ) {
+			ret = GOJS_EXCEPTION;
+			goto done;
+		}
+		items = (JSObjectRef)entries;
+	}
+
+	if ( kind != GOJS_OBJECT ) {
+		JSValueRef length = JSObjectGetProperty( f->ctx, items, f->length, f->exception );
+		if ( *f->exception ) {
+			ret = GOJS_EXCEPTION;
+			goto done;
+		}
+		// Arrays and the arrays of entries always have a length that is an
+		// integer below 2^32, but it is still checked before the cast.
+		double len = JSValueToNumber( f->ctx, length, NULL );
+		if ( !(len >= 0 && len <= GOJS_MAX_LENGTH) || len != (double)(unsigned)len ) {
+			ret = GOJS_TOO_LONG;
+			goto done;
+		}
+		unsigned n = (unsigned)len;
+		flatten_node( f, kind, 0, n );
+		unsigned i;
+		for ( i=0; i<n && ret==GOJS_OK; ++i ) {
+			JSValueRef item = JSObjectGetPropertyAtIndex( f->ctx, items, i, f->exception );
+			if ( *f->exception ) {
+				ret = GOJS_EXCEPTION;
+			} else if ( kind == GOJS_MAP ) {
+				// Each entry is a [key, value] array.
+				JSObjectRef entry = (JSObjectRef)item;
+				JSValueRef key = JSObjectGetPropertyAtIndex( f->ctx, entry, 0, NULL );
+				ret = flatten_value( f, key, 1 );
+				if ( ret == GOJS_OK ) {
+					ret = flatten_value( f, JSObjectGetPropertyAtIndex( f->ctx, entry, 1, NULL ), 1 );
+				}
+			} else {
+				ret = flatten_value( f, item, 1 );
+			}
+		}
+		goto done;
+	}
+
+	size_t header = flatten_node( f, GOJS_OBJECT, 0, 0 );
+	size_t children = 0;
+	JSPropertyNameArrayRef names = JSObjectCopyPropertyNames( f->ctx, obj );
+	size_t n = JSPropertyNameArrayGetCount( names );
+	size_t i;
+	for ( i=0; i<n && ret==GOJS_OK; ++i ) {
+		JSStringRef name = JSPropertyNameArrayGetNameAtIndex( names, i );
+		JSValueRef item = JSObjectGetProperty( f->ctx, obj, name, f->exception );
+		if ( *f->exception ) {
+			ret = GOJS_EXCEPTION;
+			break;
+		}
+		// Like JSON.stringify, leave out properties that have no Go form.
+		if ( JSValueIsUndefined( f->ctx, item ) ) {
+			continue;
+		}
+		if ( JSValueIsObject( f->ctx, item ) && JSObjectIsFunction( f->ctx, (JSObjectRef)item ) ) {
+			continue;
+		}
+		if ( flatten_is_symbol( f, item ) ) {
+			continue;
+		}
+		flatten_string( f, GOJS_STRING, name );
+		ret = flatten_value( f, item, 0 );
+		++children;
+	}
+	JSPropertyNameArrayRelease( names );
+	if ( !f->nomem ) {
+		f->nodes[header].len = children;
+	}
+
+done:
+	--f->depth;
+	return ret;
+}
+
+static int flatten_value(flattener* f, JSValueRef value, int inArray)
+{
+	if ( f->nomem ) {
+		return GOJS_NO_MEMORY;
+	}
+	switch ( JSValueGetType( f->ctx, value ) ) {
+	case kJSTypeUndefined:
+		flatten_node( f, inArray ? GOJS_NULL : GOJS_UNDEFINED, 0, 0 );
+		return GOJS_OK;
+	case kJSTypeNull:
+		flatten_node( f, GOJS_NULL, 0, 0 );
+		return GOJS_OK;
+	case kJSTypeBoolean:
+		flatten_node( f, GOJS_BOOLEAN, JSValueToBoolean( f->ctx, value ), 0 );
+		return GOJS_OK;
+	case kJSTypeNumber:
+		flatten_node( f, GOJS_NUMBER, JSValueToNumber( f->ctx, value, NULL ), 0 );
+		return GOJS_OK;
+	case kJSTypeString: {
+		JSStringRef str = JSValueToStringCopy( f->ctx, value, NULL );
+		flatten_string( f, GOJS_STRING, str );
+		JSStringRelease( str );
+		return GOJS_OK;
+	}
+	default:
+		break;
+	}
+
+	if ( !JSValueIsObject( f->ctx, value ) ) {
+		JSStringRef str = flatten_bigint( f, value );
+		if ( str ) {
+			flatten_string( f, GOJS_BIGINT, str );
+			JSStringRelease( str );
+			return GOJS_OK;
+		}
+	}
+	if ( !JSValueIsObject( f->ctx, value ) || JSObjectIsFunction( f->ctx, (JSObjectRef)value ) ) {
+		// Symbols and functions, which only reach here as array elements.
+		flatten_node( f, GOJS_NULL, 0, 0 );
+		return GOJS_OK;
+	}
+	if ( JSValueIsInstanceOfConstructor( f->ctx, value, f->builtins->date, NULL ) ) {
+		flatten_node( f, GOJS_DATE, JSValueToNumber( f->ctx, value, NULL ), 0 );
+		return GOJS_OK;
+	}
+	return flatten_object( f, (JSObjectRef)value );
+}
+
+// Flattens value into *nodes and *chars, which are allocated with malloc and
+// grown as the value is walked, so that getters run only once. The buffers
+// must be freed by the caller, whatever the result.
+int gojs_flatten(JSContextRef ctx, JSValueRef value, const gojs_builtins* builtins,
+	gojs_node** nodes, size_t* nodeCount, JSChar** chars, size_t* charCount,
+	JSValueRef* exception)
+{
+	*nodes = NULL;
+	*chars = NULL;
+	*nodeCount = 0;
+	*charCount = 0;
+	flattener* f = calloc( 1, sizeof(flattener) );
+	if ( !f ) {
+		return GOJS_NO_MEMORY;
+	}
+	f->ctx = ctx;
+	f->builtins = builtins;
+	f->length = JSStringCreateWithUTF8CString( "length" );
+	f->exception = exception;
+
+	int ret = flatten_value( f, value, 0 );
+	if ( f->nomem ) {
+		ret = GOJS_NO_MEMORY;
+	}
+	*nodes = f->nodes;
+	*chars = f->chars;
+	*nodeCount = f->nodeCount;
+	*charCount = f->charCount;
+
+	JSStringRelease( f->length );
+	free( f );
+	return ret;
+}
+
+//=========================================================
+// Building, the reverse of flattening
+//---------------------------------------------------------
+
+typedef struct {
+	JSContextRef ctx;
+	const gojs_node* nodes;
+	size_t index;
+	const JSChar* chars;
+	const gojs_makers* makers;
+	JSValueRef* exception;
+} builder;
+
+static JSValueRef build_value(builder* b)
+{
+	JSContextRef ctx = b->ctx;
+	const JSChar* chars = b->chars;
+	JSValueRef* exception = b->exception;
+	const gojs_node* node = &b->nodes[b->index++];
+	switch ( node->kind ) {
+	case GOJS_NULL:
+		return JSValueMakeNull( ctx );
+	case GOJS_BOOLEAN:
+		return JSValueMakeBoolean( ctx, node->number != 0 );
+	case GOJS_NUMBER:
+		return JSValueMakeNumber( ctx, node->number );
+	case GOJS_STRING: {
+		JSStringRef str = JSStringCreateWithCharacters( chars+node->offset, node->len );
+		JSValueRef ret = JSValueMakeString( ctx, str );
+		JSStringRelease( str );
+		return ret;
+	}
+	case GOJS_BIGINT: {
+		if ( !b->makers->bigint ) {
+			return JSValueMakeUndefined( ctx );
+		}
+		JSStringRef str = JSStringCreateWithCharacters( chars+node->offset, node->len );
+		JSValueRef arg = JSValueMakeString( ctx, str );
+		JSStringRelease( str );
+		return JSObjectCallAsFunction( ctx, b->makers->bigint, NULL, 1, &arg, exception );
+	}
+	case GOJS_DATE: {
+		JSValueRef ms = JSValueMakeNumber( ctx, node->number );
+		return JSObjectMakeDate( ctx, 1, &ms, exception );
+	}
+	case GOJS_ARRAY: {
+		// Elements go straight into the array, which the collector can see,
+		// rather than into a buffer on the heap, which it cannot.
+		JSObjectRef arr = JSObjectMakeArray( ctx, 0, NULL, exception );
+		if ( !arr ) {
+			return NULL;
+		}
+		size_t i;
+		for ( i=0; i<node->len; ++i ) {
+			JSValueRef item = build_value( b );
+			if ( !item ) {
+				return NULL;
+			}
+			JSObjectSetPropertyAtIndex( ctx, arr, (unsigned)i, item, exception );
+			if ( *exception ) {
+				return NULL;
+			}
+		}
+		return arr;
+	}
+	case GOJS_OBJECT: {
+		JSObjectRef obj = JSObjectMake( ctx, NULL, NULL );
+		size_t i;
+		for ( i=0; i<node->len; ++i ) {
+			const gojs_node* key = &b->nodes[b->index++];
+			JSValueRef item = build_value( b );
+			if ( !item ) {
+				return NULL;
+			}
+			JSStringRef name = JSStringCreateWithCharacters( chars+key->offset, key->len );
+			JSObjectSetProperty( ctx, obj, name, item, kJSPropertyAttributeNone, exception );
+			JSStringRelease( name );
+			if ( *exception ) {
+				return NULL;
+			}
+		}
+		return obj;
+	}
+	case GOJS_MAP:
+	case GOJS_SET: {
+		// The entries or values are gathered in an array, as for GOJS_ARRAY,
+		// and handed to the maker.
+		JSObjectRef maker = node->kind == GOJS_MAP ? b->makers->map : b->makers->set;
+		JSObjectRef arr = JSObjectMakeArray( ctx, 0, NULL, exception );
+		if ( !arr ) {
+			return NULL;
+		}
+		size_t i;
+		for ( i=0; i<node->len; ++i ) {
+			JSValueRef item = build_value( b );
+			if ( !item ) {
+				return NULL;
+			}
+			if ( node->kind == GOJS_MAP ) {
+				JSValueRef entry[2] = { item, build_value( b ) };
+				if ( !entry[1] ) {
+					return NULL;
+				}
+				item = JSObjectMakeArray( ctx, 2, entry, exception );
+				if ( !item ) {
+					return NULL;
+				}
+			}
+			JSObjectSetPropertyAtIndex( ctx, arr, (unsigned)i, item, exception );
+			if ( *exception ) {
+				return NULL;
+			}
+		}
+		if ( !maker ) {
+			return JSValueMakeUndefined( ctx );
+		}
+		JSValueRef arg = arr;
+		return JSObjectCallAsFunction( ctx, maker, NULL, 1, &arg, exception );
+	}
+	}
+	return JSValueMakeUndefined( ctx );
+}
+
+// Builds the value described by the count nodes of a flattened tree, with
+// makers building the values it cannot build directly. It returns NULL if an
+// exception was thrown or memory ran out.
+JSValueRef gojs_build(JSContextRef ctx, const gojs_node* nodes, size_t count, const JSChar* chars, const gojs_makers* makers, JSValueRef* exception)
+{
+	if ( count == 0 ) {
+		return JSValueMakeUndefined( ctx );
+	}
+	builder b = { ctx, nodes, 0, chars, makers, exception };
+	return build_value( &b );
+}
@@ -0,0 +1,71 @@
+#include <JavaScriptCore/JSObjectRef.h>
+#include <JavaScriptCore/JSStringRef.h>
+
+void gojs_array_values(JSContextRef ctx, JSObjectRef obj, unsigned start, JSValueRef* out, unsigned count, JSValueRef* exception);
+char* gojs_property_names(JSContextRef ctx, JSObjectRef obj, size_t* count, size_t** lengths);
+
+// Kinds of gojs_node.
+enum {
+	GOJS_UNDEFINED,
+	GOJS_NULL,
+	GOJS_BOOLEAN,
+	GOJS_NUMBER,
+	GOJS_STRING,
+	GOJS_ARRAY,
+	GOJS_OBJECT,
+	GOJS_DATE,
+	GOJS_MAP, // followed by a key node and a value node for each entry
+	GOJS_SET, // followed by its values
+	GOJS_BIGINT, // a decimal string, like GOJS_STRING
+};
+
+// A gojs_node is one value in the flattened form of a tree of JavaScript
+// values. Arrays are followed by their elements, and objects by a string
+// node for each key followed by its value.
+typedef struct {
+	int kind;
+	double number; // booleans, numbers, and dates as milliseconds
+	size_t offset; // strings, as an offset into the character buffer
+	size_t len;    // strings in characters, arrays and objects in children
+} gojs_node;
+
+// Results of gojs_flatten.
+enum {
+	GOJS_OK,
+	GOJS_NO_MEMORY, // the buffers could not be grown
+	GOJS_CYCLIC,    // the value contains itself
+	GOJS_TOO_DEEP,  // the value is nested too deeply
+	GOJS_TOO_LONG,  // an array, Map or Set has too many elements
+	GOJS_EXCEPTION, // a getter threw
+};
+
+#define GOJS_MAX_DEPTH 512
+#define GOJS_MAX_LENGTH (1 << 24)
+
+// The built-ins gojs_flatten recognizes. isArray is Array.isArray, entries
+// is a function that returns the entries of a Map or the values of a Set as
+// an array, as Array.from, and bigint one that returns the decimal string of
+// a BigInt and undefined for anything else.
+typedef struct {
+	JSObjectRef isArray;
+	JSObjectRef date;
+	JSObjectRef map;
+	JSObjectRef set;
+	JSObjectRef entries;
+	JSObjectRef bigint;
+} gojs_builtins;
+
+int gojs_flatten(JSContextRef ctx, JSValueRef value, const gojs_builtins* builtins,
+	gojs_node** nodes, size_t* nodeCount, JSChar** chars, size_t* charCount,
+	JSValueRef* exception);
+// The functions gojs_build calls to make values it cannot make directly.
+// bigint converts a decimal string to a BigInt, map an array of [key, value]
+// entries to a Map, and set an array of values to a Set. Each may be NULL
+// where the nodes hold no such value.
+typedef struct {
+	JSObjectRef bigint;
+	JSObjectRef map;
+	JSObjectRef set;
+} gojs_makers;
+
+JSValueRef gojs_build(JSContextRef ctx, const gojs_node* nodes, size_t count, const JSChar* chars, const gojs_makers* makers, JSValueRef* exception);
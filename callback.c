@@ -149,11 +149,28 @@ static bool nativeobject_SetProperty(JSContextRef ctx, JSObjectRef object, JSStr
 	return nativeobject_SetProperty_go( data, (void*)ctx, (void*)object, propertyName, (void*)value, exception );
 }
 
+static bool nativeobject_DeleteProperty(JSContextRef ctx, JSObjectRef object, JSStringRef propertyName, JSValueRef* exception)
+{
+	assert( exception );
+
+	void* data = JSObjectGetPrivate( object );
+	return nativeobject_DeleteProperty_go( data, (void*)ctx, propertyName, exception );
+}
+
+static void nativeobject_GetPropertyNames(JSContextRef ctx, JSObjectRef object, JSPropertyNameAccumulatorRef propertyNames)
+{
+	void* data = JSObjectGetPrivate( object );
+	nativeobject_GetPropertyNames_go( data, (void*)ctx, propertyNames );
+}
+
 static JSValueRef nativeobject_ConvertToType(JSContextRef ctx, JSObjectRef object, JSType type, JSValueRef* exception)
 {
 	if ( type == kJSTypeString ) {
 		void* data = JSObjectGetPrivate( object );
-		JSStringRef str = nativeobject_ConvertToString_go( data, (void*)ctx, (void*)object );
+		JSStringRef str = nativeobject_ConvertToString_go( data, (void*)ctx, (void*)object, exception );
+		if ( *exception ) {
+			return NULL;
+		}
 		if ( !str ) {
 			str = JSStringCreateWithUTF8CString( "nativeobject" );
 		}
@@ -162,6 +179,17 @@ static JSValueRef nativeobject_ConvertToType(JSContextRef ctx, JSObjectRef objec
 		return ret;
 	}
 
+	if ( type == kJSTypeNumber ) {
+		void* data = JSObjectGetPrivate( object );
+		double number;
+		if ( nativeobject_ConvertToNumber_go( data, (void*)ctx, &number, exception ) ) {
+			return JSValueMakeNumber( ctx, number );
+		}
+		if ( *exception ) {
+			return NULL;
+		}
+	}
+
 	return 0;
 }
 
@@ -179,8 +207,8 @@ JSClassRef JSClassDefinition_NativeObject()
 		NULL, // hasProperty;
 		nativeobject_GetProperty, // getProperty;
 		nativeobject_SetProperty, // setProperty;
-		NULL, // deleteProperty;
-		NULL, // getPropertyNames;
+		nativeobject_DeleteProperty, // deleteProperty;
+		nativeobject_GetPropertyNames, // getPropertyNames;
 		NULL, // callAsFunction;
 		NULL, // callAsConstructor;
 		NULL, // hasInstance;
@@ -248,3 +276,54 @@ JSClassRef JSClassDefinition_NativeMethod()
 	return JSClassCreate( &def );
 }
 
+
+//=========================================================
+// Native Type
+//---------------------------------------------------------
+
+static void nativetype_Finalize(JSObjectRef object)
+{
+	void* data = JSObjectGetPrivate( object );
+	finalize_go( data );
+}
+
+static JSObjectRef nativetype_CallAsConstructor(JSContextRef ctx, JSObjectRef constructor, size_t argumentCount, const JSValueRef arguments[], JSValueRef* exception)
+{
+	assert( exception );
+
+	void* data = JSObjectGetPrivate( constructor );
+	JSObjectRef ret = nativetype_CallAsConstructor_go( data, ctx, argumentCount, (void*)arguments, exception );
+	assert( *exception==NULL || (*exception && !ret) );
+	return ret;
+}
+
+static bool nativetype_HasInstance(JSContextRef ctx, JSObjectRef constructor, JSValueRef possibleInstance, JSValueRef* exception)
+{
+	void* data = JSObjectGetPrivate( constructor );
+	return nativetype_HasInstance_go( data, ctx, possibleInstance );
+}
+
+JSClassRef JSClassDefinition_NativeType()
+{
+	static JSClassDefinition def = {
+		0,
+		kJSClassAttributeNone,
+		"nativetype",
+		NULL, // parentClass
+		NULL, // staticValues;
+		NULL, // staticFunctions;
+		NULL, // initialize;
+		nativetype_Finalize, // finalize;
+		NULL, // hasProperty;
+		NULL, // getProperty;
+		NULL, // setProperty;
+		NULL, // deleteProperty;
+		NULL, // getPropertyNames;
+		NULL, // callAsFunction;
+		nativetype_CallAsConstructor, // callAsConstructor;
+		nativetype_HasInstance, // hasInstance;
+		NULL // convertToType;
+	};
+
+	return JSClassCreate( &def );
+}
@@ -4,4 +4,5 @@ JSClassRef JSClassDefinition_NativeCallback();
 JSClassRef JSClassDefinition_NativeFunction();
 JSClassRef JSClassDefinition_NativeObject();
 JSClassRef JSClassDefinition_NativeMethod();
+JSClassRef JSClassDefinition_NativeType();
 
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errInterrupt is returned by readLine when the user presses Ctrl-C.
+var errInterrupt = errors.New("interrupted")
+
+// lineReader reads the shell's input one line at a time. history lists
+// previous input, oldest first, for readers that offer recall.
+type lineReader interface {
+	readLine(prompt string, history []string) (string, error)
+}
+
+// plainReader reads lines from input that is not a terminal.
+type plainReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func newPlainReader(in io.Reader, out io.Writer) *plainReader {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &plainReader{scanner, out}
+}
+
+func (p *plainReader) readLine(prompt string, _ []string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	if p.scanner.Scan() {
+		return p.scanner.Text(), nil
+	}
+	if err := p.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// lineEditor reads lines from a terminal with emacs-style editing keys and
+// recall of previous input with the up and down arrows.
+type lineEditor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// raw, if set, puts the terminal in raw mode while a line is edited and
+	// returns a function restoring it.
+	raw func() (func(), error)
+}
+
+func ctrl(c rune) rune {
+	return c & 0x1f
+}
+
+// keyDelete is the forward delete key, which unlike Ctrl-D never ends the
+// input.
+const keyDelete rune = -1
+
+func (e *lineEditor) readLine(prompt string, history []string) (string, error) {
+	if e.raw != nil {
+		if restore, err := e.raw(); err == nil {
+			defer restore()
+		}
+	}
+	fmt.Fprint(e.out, prompt)
+
+	var line, draft []rune
+	pos := 0
+	// recall is the index in history of the line shown; len(history) is
+	// the line being typed, kept in draft while history is browsed.
+	recall := len(history)
+	show := func(i int) {
+		if i < 0 || i > len(history) || i == recall {
+			return
+		}
+		if recall == len(history) {
+			draft = line
+		}
+		recall = i
+		if i == len(history) {
+			line = draft
+		} else {
+			line = []rune(history[i])
+		}
+		pos = len(line)
+	}
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return string(line), nil
+			}
+			return "", err
+		}
+		if r == 0x1b {
+			r = e.escape()
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case ctrl('C'):
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupt
+		case ctrl('D'):
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			fallthrough
+		case keyDelete:
+			if pos < len(line) {
+				line = append(line[:pos:pos], line[pos+1:]...)
+			}
+		case 0x7f, ctrl('H'):
+			if pos > 0 {
+				line = append(line[:pos-1:pos-1], line[pos:]...)
+				pos--
+			}
+		case ctrl('A'):
+			pos = 0
+		case ctrl('E'):
+			pos = len(line)
+		case ctrl('B'):
+			if pos > 0 {
+				pos--
+			}
+		case ctrl('F'):
+			if pos < len(line) {
+				pos++
+			}
+		case ctrl('P'):
+			show(recall - 1)
+		case ctrl('N'):
+			show(recall + 1)
+		case ctrl('K'):
+			line = line[:pos:pos]
+		case ctrl('U'):
+			line = append([]rune(nil), line[pos:]...)
+			pos = 0
+		case ctrl('W'):
+			start := pos
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start:start], line[pos:]...)
+			pos = start
+		default:
+			if r < ' ' {
+				continue
+			}
+			line = append(line[:pos:pos], append([]rune{r}, line[pos:]...)...)
+			pos++
+		}
+
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+		if n := len(line) - pos; n > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", n)
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence and returns the control key
+// with the same meaning, or 0 for sequences it does not know.
+func (e *lineEditor) escape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	var num []rune
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return 0
+		}
+		if r < '0' || r > '9' {
+			break
+		}
+		num = append(num, r)
+	}
+	switch r {
+	case 'A':
+		return ctrl('P')
+	case 'B':
+		return ctrl('N')
+	case 'C':
+		return ctrl('F')
+	case 'D':
+		return ctrl('B')
+	case 'H':
+		return ctrl('A')
+	case 'F':
+		return ctrl('E')
+	case '~':
+		switch string(num) {
+		case "1", "7":
+			return ctrl('A')
+		case "4", "8":
+			return ctrl('E')
+		case "3":
+			return keyDelete
+		}
+	}
+	return 0
+}
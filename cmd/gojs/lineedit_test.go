@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	history := []string{"first", "second"}
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"abc\r", "abc"},
+		{"abc\x1b[D\x1b[DX\r", "aXbc"},
+		{"abc\x7f\x7fd\r", "ad"},
+		{"abc\x01X\x05Y\r", "XabcY"},
+		{"one two\x17three\r", "one three"},
+		{"abc\x1b[D\x0b\r", "ab"},
+		{"abc\x1b[D\x15\r", "c"},
+		{"abc\x1b[H\x1b[3~\r", "bc"},
+		{"\x1b[A\r", "second"},
+		{"\x1b[A\x1b[A\r", "first"},
+		{"\x1b[A\x1b[A\x1b[A\r", "first"},
+		{"draft\x1b[A\x1b[B\r", "draft"},
+		{"\x1b[A!\r", "second!"},
+		{"é\x1b[Dà\r", "àé"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		e := &lineEditor{in: bufio.NewReader(strings.NewReader(test.keys)), out: &out}
+		got, err := e.readLine("> ", history)
+		if err != nil || got != test.want {
+			t.Errorf("readLine(%q) = %q, %v, want %q", test.keys, got, err, test.want)
+		}
+	}
+
+	e := &lineEditor{in: bufio.NewReader(strings.NewReader("abc\x03\x04")), out: io.Discard}
+	if _, err := e.readLine("> ", nil); err != errInterrupt {
+		t.Errorf("readLine after Ctrl-C returned %v, want errInterrupt", err)
+	}
+	if _, err := e.readLine("> ", nil); err != io.EOF {
+		t.Errorf("readLine after Ctrl-D returned %v, want io.EOF", err)
+	}
+}
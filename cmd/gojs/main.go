@@ -0,0 +1,147 @@
+// Command gojs runs JavaScript through JavaScriptCore using gojs.
+//
+// Usage:
+//
+//	gojs [flags]                        start an interactive shell
+//	gojs run [flags] script.js [args]   run a script
+//
+// In the shell, lines are evaluated in a single persistent context. Input
+// that is not yet a complete statement continues on the next line. Commands
+// start with a dot; type .help for the list. At a terminal, lines can be
+// edited with the usual emacs keys, and previous input, kept in
+// ~/.gojs_history, is recalled with the up and down arrows.
+//
+// Scripts started with run see their arguments in process.argv and the
+// environment in process.env, and can end the program with process.exit.
+// The command exits with status 1 if a script throws an uncaught exception.
+//
+// Both modes accept these flags:
+//
+//	-console=true   install the console object
+//	-timers=true    install setTimeout, setInterval and friends
+//	-bind NAME      install the host binding registered under NAME
+//	-plugin FILE    load a Go plugin that registers host bindings
+//
+// Host bindings are registered from Go with gojs.RegisterHostBinding. Those
+// registered by a plugin are installed automatically unless -bind is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"github.com/crazy2be/gojs"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runMain(os.Args[2:]))
+	}
+	replMain(os.Args[1:])
+}
+
+// stringsFlag collects the values of a flag that may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// hostFlags are the flags shared by the shell and the script runner.
+type hostFlags struct {
+	console bool
+	timers  bool
+	binds   stringsFlag
+	plugins stringsFlag
+}
+
+func (h *hostFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&h.console, "console", true, "install the console object")
+	fs.BoolVar(&h.timers, "timers", true, "install setTimeout, setInterval and friends")
+	fs.Var(&h.binds, "bind", "install the named host binding (repeatable)")
+	fs.Var(&h.plugins, "plugin", "load a Go plugin that registers host bindings (repeatable)")
+}
+
+// setup prepares ctx according to the flags. It returns the event loop if
+// timers were requested.
+func (h *hostFlags) setup(ctx *gojs.Context) (*eventLoop, error) {
+	if h.console {
+		if err := ctx.InstallConsole(gojs.NewWriterConsoleSink(os.Stdout)); err != nil {
+			return nil, fmt.Errorf("installing console: %v", err)
+		}
+	}
+
+	var loop *eventLoop
+	if h.timers {
+		loop = newEventLoop(ctx, os.Stderr)
+		if err := loop.install(); err != nil {
+			return nil, fmt.Errorf("installing timers: %v", err)
+		}
+	}
+
+	binds := h.binds
+	if len(h.plugins) > 0 {
+		before := make(map[string]bool)
+		for _, name := range gojs.HostBindings() {
+			before[name] = true
+		}
+		for _, path := range h.plugins {
+			if _, err := plugin.Open(path); err != nil {
+				return nil, err
+			}
+		}
+		if len(binds) == 0 {
+			for _, name := range gojs.HostBindings() {
+				if !before[name] {
+					binds = append(binds, name)
+				}
+			}
+		}
+	}
+	for _, name := range binds {
+		if err := ctx.InstallHostBinding(name); err != nil {
+			return nil, err
+		}
+	}
+	return loop, nil
+}
+
+func replMain(args []string) {
+	fs := flag.NewFlagSet("gojs", flag.ExitOnError)
+	var host hostFlags
+	host.register(fs)
+	history := fs.String("history", defaultHistoryFile(), "file to keep input history in (empty to disable)")
+	fs.Parse(args)
+
+	ctx := gojs.NewContext()
+	defer ctx.Release()
+
+	loop, err := host.setup(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gojs:", err)
+		os.Exit(1)
+	}
+
+	r := newREPL(ctx, loop, os.Stdin, os.Stdout)
+	if *history != "" {
+		r.loadHistory(*history)
+	}
+	r.run()
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gojs_history")
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crazy2be/gojs"
+)
+
+// maxHistory is the number of entries kept in the history file.
+const maxHistory = 1000
+
+const replHelp = `.load FILE   evaluate a JavaScript file
+.globals     list the enumerable global variables
+.gc          run the garbage collector
+.history     show previous input (recall it with the arrow keys)
+.exit        leave the shell
+.help        show this message`
+
+type repl struct {
+	ctx  *gojs.Context
+	loop *eventLoop
+	out  io.Writer
+
+	// Input is read on its own goroutine, so that timers run while the
+	// shell waits for it.
+	requests chan lineRequest
+	results  chan lineResult
+
+	history     []string
+	historyFile string
+}
+
+type lineRequest struct {
+	prompt  string
+	history []string
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+// newREPL creates a shell reading from in. Input from a terminal can be
+// edited, and previous lines recalled with the arrow keys.
+func newREPL(ctx *gojs.Context, loop *eventLoop, in io.Reader, out io.Writer) *repl {
+	var reader lineReader = newPlainReader(in, out)
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		reader = &lineEditor{
+			in:  bufio.NewReader(f),
+			out: out,
+			raw: func() (func(), error) { return makeRaw(f.Fd()) },
+		}
+	}
+
+	r := &repl{
+		ctx:      ctx,
+		loop:     loop,
+		out:      out,
+		requests: make(chan lineRequest),
+		results:  make(chan lineResult),
+	}
+	go func() {
+		for req := range r.requests {
+			line, err := reader.readLine(req.prompt, req.history)
+			r.results <- lineResult{line, err}
+		}
+	}()
+	return r
+}
+
+func (r *repl) run() {
+	prompt := "> "
+	var buf []string
+	for {
+		line, err := r.readLine(prompt)
+		if err == errInterrupt {
+			buf, prompt = nil, "> "
+			continue
+		}
+		if err != nil {
+			fmt.Fprintln(r.out)
+			return
+		}
+
+		if len(buf) == 0 && strings.HasPrefix(strings.TrimSpace(line), ".") {
+			if !r.command(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		buf = append(buf, line)
+		src := strings.Join(buf, "\n")
+		if strings.TrimSpace(src) == "" {
+			buf = nil
+			continue
+		}
+		// An empty line forces evaluation, so that a mistake cannot trap
+		// the user in continuation mode.
+		if line != "" && incomplete(r.ctx, src) {
+			prompt = "... "
+			continue
+		}
+		buf, prompt = nil, "> "
+
+		r.record(src)
+		r.eval(src, "repl")
+	}
+}
+
+// readLine prompts for the next line of input and waits for it, running
+// timers that fall due in the meantime.
+func (r *repl) readLine(prompt string) (string, error) {
+	r.requests <- lineRequest{prompt, r.recallLines()}
+	for {
+		var timeout <-chan time.Time
+		if r.loop.pending() {
+			when, _ := r.loop.next()
+			timeout = time.After(time.Until(when))
+		}
+		select {
+		case res := <-r.results:
+			return res.line, res.err
+		case <-timeout:
+			r.loop.runDue()
+		}
+	}
+}
+
+// recallLines lists the lines of previous input for recall, oldest first.
+// Multi-line entries are recalled a line at a time.
+func (r *repl) recallLines() []string {
+	var lines []string
+	for _, entry := range r.history {
+		for _, line := range strings.Split(entry, "\n") {
+			if n := len(lines); n == 0 || lines[n-1] != line {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// incomplete reports whether src is a syntax error only because it ends
+// too early, meaning more input should be read before evaluating it.
+func incomplete(ctx *gojs.Context, src string) bool {
+	err := ctx.CheckScriptSyntax(src, "repl", 1)
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "end of") || strings.Contains(msg, "unexpected eof")
+}
+
+func (r *repl) eval(src, sourceURL string) {
+	ret, err := r.ctx.EvaluateScript(src, nil, sourceURL, 1)
+	if err != nil {
+		fmt.Fprintln(r.out, "Uncaught", err)
+	} else if ret != nil {
+		fmt.Fprintln(r.out, ret.Inspect(nil))
+	}
+	if r.loop.pending() {
+		r.loop.runDue()
+	}
+}
+
+// command runs a dot command. It returns false if the shell should exit.
+func (r *repl) command(line string) bool {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case ".exit":
+		return false
+	case ".help":
+		fmt.Fprintln(r.out, replHelp)
+	case ".load":
+		if arg == "" {
+			fmt.Fprintln(r.out, "usage: .load FILE")
+			break
+		}
+		src, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			break
+		}
+		r.eval(string(src), arg)
+	case ".globals":
+		globals := r.ctx.GlobalObject().Keys()
+		sort.Strings(globals)
+		for _, name := range globals {
+			fmt.Fprintln(r.out, name)
+		}
+	case ".gc":
+		r.ctx.GarbageCollect()
+	case ".history":
+		for i, entry := range r.history {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, entry)
+		}
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, try .help\n", name)
+	}
+	return true
+}
+
+// loadHistory reads previous input from path and appends new input to it
+// from then on. Entries are stored one per line as quoted Go strings, so
+// that multi-line input survives the round trip.
+func (r *repl) loadHistory(path string) {
+	r.historyFile = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if entry, err := strconv.Unquote(line); err == nil {
+			r.history = append(r.history, entry)
+		}
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+		r.saveHistory()
+	}
+}
+
+func (r *repl) record(entry string) {
+	if n := len(r.history); n > 0 && r.history[n-1] == entry {
+		return
+	}
+	r.history = append(r.history, entry)
+	if r.historyFile == "" {
+		return
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[1:]
+		r.saveHistory()
+		return
+	}
+	f, err := os.OpenFile(r.historyFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, strconv.Quote(entry))
+}
+
+func (r *repl) saveHistory() {
+	var b strings.Builder
+	for _, entry := range r.history {
+		b.WriteString(strconv.Quote(entry) + "\n")
+	}
+	os.WriteFile(r.historyFile, []byte(b.String()), 0600)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazy2be/gojs"
+)
+
+func TestIncomplete(t *testing.T) {
+	ctx := gojs.NewContext()
+	defer ctx.Release()
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"1 + 2", false},
+		{"function f() {", true},
+		{"[1, 2,", true},
+		{"if (x) {\n  y()", true},
+		{"1 +* 2", false},
+		{"}", false},
+	}
+	for _, test := range tests {
+		if got := incomplete(ctx, test.src); got != test.want {
+			t.Errorf("incomplete(%q) = %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestREPL(t *testing.T) {
+	ctx := gojs.NewContext()
+	defer ctx.Release()
+
+	loop := newEventLoop(ctx, os.Stderr)
+	if err := loop.install(); err != nil {
+		t.Fatalf("loop.install returned an error (%v)", err)
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "lib.js")
+	if err := os.WriteFile(script, []byte("var fromFile = 42;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history := filepath.Join(dir, "history")
+
+	in := strings.Join([]string{
+		"var answer = 6 *",
+		"7",
+		".load " + script,
+		"fromFile",
+		"var fired = false; setTimeout(function () { fired = true; }, 0)",
+		".globals",
+		"fired",
+		"throw new Error('boom')",
+	}, "\n")
+	var out bytes.Buffer
+	r := newREPL(ctx, loop, strings.NewReader(in), &out)
+	r.loadHistory(history)
+	r.run()
+
+	got := out.String()
+	for _, want := range []string{"... ", "42", "answer\nfired\nfromFile\n", "true", "Uncaught Error: boom"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("REPL output does not contain %q:\n%s", want, got)
+		}
+	}
+
+	data, err := os.ReadFile(history)
+	if err != nil {
+		t.Fatalf("history was not saved (%v)", err)
+	}
+	if !strings.HasPrefix(string(data), `"var answer = 6 *\n7"`) {
+		t.Errorf("history file starts with %q", data)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/crazy2be/gojs"
+)
+
+func runMain(args []string) int {
+	fs := flag.NewFlagSet("gojs run", flag.ExitOnError)
+	var host hostFlags
+	host.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gojs run [flags] script.js [args]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx := gojs.NewContext()
+	defer ctx.Release()
+
+	loop, err := host.setup(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gojs:", err)
+		return 1
+	}
+
+	r := &runner{ctx: ctx, loop: loop, stderr: os.Stderr}
+	return r.run(fs.Arg(0), fs.Args()[1:])
+}
+
+// runner evaluates a script file and then runs its timers to completion.
+type runner struct {
+	ctx    *gojs.Context
+	loop   *eventLoop
+	stderr io.Writer
+
+	exited bool
+	code   int
+}
+
+// run evaluates the script at path and returns the process exit status.
+func (r *runner) run(path string, args []string) int {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(r.stderr, "gojs:", err)
+		return 1
+	}
+	if err := r.installProcess(path, args); err != nil {
+		fmt.Fprintln(r.stderr, "gojs: installing process:", err)
+		return 1
+	}
+	if r.loop != nil {
+		r.loop.onError = r.uncaught
+	}
+
+	if _, err := r.ctx.EvaluateScript(string(src), nil, path, 1); err != nil {
+		r.uncaught(err)
+	}
+	if !r.exited {
+		r.loop.drain()
+	}
+	return r.code
+}
+
+func (r *runner) uncaught(err error) {
+	if r.exited {
+		// The exception unwinding a call to process.exit.
+		return
+	}
+	fmt.Fprintln(r.stderr, formatException(r.ctx, err))
+	r.exit(1)
+}
+
+// exit ends the script with code. The context is terminated, so that a
+// script catching the exception thrown by process.exit can no longer call
+// the host, set timers or exit again.
+func (r *runner) exit(code int) {
+	r.exited = true
+	r.code = code
+	r.ctx.Terminate()
+	if r.loop != nil {
+		r.loop.stop()
+	}
+}
+
+// installProcess defines the process global with argv, env and exit.
+func (r *runner) installProcess(path string, args []string) error {
+	ctx := r.ctx
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "gojs"
+	}
+	argv := []*gojs.Value{ctx.NewStringValue(exe), ctx.NewStringValue(path)}
+	for _, arg := range args {
+		argv = append(argv, ctx.NewStringValue(arg))
+	}
+	argvObj, err := ctx.NewArray(argv)
+	if err != nil {
+		return err
+	}
+
+	env := make(map[string]*gojs.Value)
+	for _, kv := range os.Environ() {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			env[kv[:i]] = ctx.NewStringValue(kv[i+1:])
+		}
+	}
+	envObj, err := ctx.NewObjectWithProperties(env)
+	if err != nil {
+		return err
+	}
+
+	// process.exit cannot stop the script from Go, so it throws to unwind
+	// it, and the exception is recognised and ignored by uncaught. A script
+	// that catches it runs on, but exit terminates the context, so it can
+	// no longer reach the host. Codes that are not finite numbers exit
+	// with 0, as in Node.
+	exit := func(ctx *gojs.Context, _, _ *gojs.Object, args []*gojs.Value) *gojs.Value {
+		code := 0
+		if len(args) > 0 {
+			if n, err := args[0].ToNumber(); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+				code = int(n)
+			}
+		}
+		r.exit(code)
+		panic(gojs.ErrTerminated)
+	}
+
+	process, err := ctx.NewObjectWithProperties(map[string]*gojs.Value{
+		"argv": argvObj.ToValue(),
+		"env":  envObj.ToValue(),
+		"exit": ctx.NewFunctionWithCallback(exit).ToValue(),
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.GlobalObject().SetWithAttributes("process", process.ToValue(), gojs.PropertyAttributeDontEnum)
+}
+
+// formatException describes an uncaught exception as
+//
+//	file:line:col: Uncaught Error: message
+//	    at fn (file:line:col)
+//
+// leaving out the location when the exception does not carry one.
+func formatException(ctx *gojs.Context, err error) string {
+	exc, ok := err.(gojs.Exception)
+	if !ok {
+		return "Uncaught " + err.Error()
+	}
+	v := exc.Value()
+	if !v.IsObject() {
+		return "Uncaught " + v.Inspect(nil)
+	}
+
+	obj := v.ToObjectOrDie()
+	prop := func(name string) string {
+		v, err := obj.Get(name)
+		if err != nil || v == nil || v.IsUndefined() {
+			return ""
+		}
+		return v.ToStringOrDie()
+	}
+	location := ""
+	if url, line := prop("sourceURL"), prop("line"); url != "" && line != "" {
+		location = url + ":" + line
+		if col := prop("column"); col != "" {
+			location += ":" + col
+		}
+		location += ": "
+	}
+	return location + "Uncaught " + v.Inspect(nil)
+}
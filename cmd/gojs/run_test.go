@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazy2be/gojs"
+)
+
+func runScript(t *testing.T, src string, args ...string) (int, string) {
+	path := filepath.Join(t.TempDir(), "script.js")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := gojs.NewContext()
+	defer ctx.Release()
+
+	var stderr bytes.Buffer
+	loop := newEventLoop(ctx, &stderr)
+	if err := loop.install(); err != nil {
+		t.Fatalf("loop.install returned an error (%v)", err)
+	}
+	r := &runner{ctx: ctx, loop: loop, stderr: &stderr}
+	code := r.run(path, args)
+	return code, strings.Replace(stderr.String(), path, "script.js", -1)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		src  string
+		args []string
+		want int
+	}{
+		{"var x = 1;", nil, 0},
+		{"process.exit(3); throw new Error('not reached')", nil, 3},
+		{"try { process.exit(2) } catch (e) {} process.exit(6)", nil, 2},
+		{"try { process.exit(2) } catch (e) {} setTimeout(function () { process.exit(6) }, 0)", nil, 2},
+		{"setTimeout(function () { try { process.exit(5) } catch (e) {} throw 'after exit' }, 0)", nil, 5},
+		{"process.exit(Number(process.argv[2]))", []string{"7"}, 7},
+		{"process.exit(NaN)", nil, 0},
+		{"process.exit(1 / 0)", nil, 0},
+		{"if (process.argv[1].indexOf('script.js') < 0) process.exit(1)", nil, 0},
+		{"setTimeout(function () { process.exit(4) }, 1); setInterval(function () {}, 1)", nil, 4},
+		{"process.exit(process.env.GOJS_TEST_ENV == 'yes' ? 0 : 1)", nil, 0},
+		{"throw new Error('boom')", nil, 1},
+		{"setTimeout(function () { throw 'late' }, 0)", nil, 1},
+	}
+
+	os.Setenv("GOJS_TEST_ENV", "yes")
+	defer os.Unsetenv("GOJS_TEST_ENV")
+
+	for _, test := range tests {
+		if code, stderr := runScript(t, test.src, test.args...); code != test.want {
+			t.Errorf("%s: exited with %d, want %d (stderr %q)", test.src, code, test.want, stderr)
+		}
+	}
+}
+
+func TestRunUncaughtStack(t *testing.T) {
+	src := "function fail() {\n  throw new Error('boom');\n}\nfail();\n"
+	code, stderr := runScript(t, src)
+	if code != 1 {
+		t.Errorf("exited with %d, want 1", code)
+	}
+	if !strings.HasPrefix(stderr, "script.js:2:") || !strings.Contains(stderr, "Uncaught Error: boom") {
+		t.Errorf("uncaught exception was reported as %q", stderr)
+	}
+	if !strings.Contains(stderr, "\n    at fail (script.js:2:") {
+		t.Errorf("uncaught exception has no stack trace: %q", stderr)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/crazy2be/gojs"
+)
+
+type timer struct {
+	id       int
+	when     time.Time
+	interval time.Duration // zero for timers created by setTimeout
+	fn       *gojs.Object
+	args     []*gojs.Value
+}
+
+// eventLoop implements setTimeout, setInterval, clearTimeout and
+// clearInterval on top of a context. Timers only fire when the owner of the
+// loop calls runDue, so scripts never run concurrently.
+type eventLoop struct {
+	ctx    *gojs.Context
+	nextID int
+	timers map[int]*timer
+
+	// onError is called with exceptions thrown by timer callbacks.
+	onError func(err error)
+	stopped bool
+}
+
+func newEventLoop(ctx *gojs.Context, errOut io.Writer) *eventLoop {
+	l := &eventLoop{ctx: ctx, timers: make(map[int]*timer)}
+	l.onError = func(err error) {
+		fmt.Fprintln(errOut, "Uncaught", err)
+	}
+	return l
+}
+
+// stop cancels every pending timer and makes the loop ignore new ones.
+func (l *eventLoop) stop() {
+	l.stopped = true
+	for id := range l.timers {
+		l.remove(id)
+	}
+}
+
+func (l *eventLoop) install() error {
+	functions := map[string]gojs.GoFunctionCallback{
+		"setTimeout":    l.set(false),
+		"setInterval":   l.set(true),
+		"clearTimeout":  l.clear,
+		"clearInterval": l.clear,
+	}
+	global := l.ctx.GlobalObject()
+	for name, fn := range functions {
+		err := global.SetWithAttributes(name, l.ctx.NewFunctionWithCallback(fn).ToValue(), gojs.PropertyAttributeDontEnum)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *eventLoop) set(repeat bool) gojs.GoFunctionCallback {
+	return func(ctx *gojs.Context, _, _ *gojs.Object, args []*gojs.Value) *gojs.Value {
+		if len(args) == 0 || !args[0].IsFunction() {
+			panic("callback must be a function")
+		}
+		var delay time.Duration
+		if len(args) > 1 {
+			if ms, err := args[1].ToNumber(); err == nil && ms > 0 {
+				delay = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+
+		l.nextID++
+		t := &timer{
+			id:   l.nextID,
+			when: time.Now().Add(delay),
+			fn:   args[0].ToObjectOrDie(),
+		}
+		if len(args) > 2 {
+			t.args = args[2:]
+		}
+		if repeat {
+			// Browsers clamp intervals so a zero delay cannot starve input.
+			t.interval = delay
+			if t.interval < time.Millisecond {
+				t.interval = time.Millisecond
+			}
+		}
+
+		if l.stopped {
+			return ctx.NewNumberValue(float64(t.id))
+		}
+		t.fn.ToValue().Protect()
+		for _, arg := range t.args {
+			arg.Protect()
+		}
+		l.timers[t.id] = t
+		return ctx.NewNumberValue(float64(t.id))
+	}
+}
+
+func (l *eventLoop) clear(ctx *gojs.Context, _, _ *gojs.Object, args []*gojs.Value) *gojs.Value {
+	if len(args) > 0 {
+		if id, err := args[0].ToNumber(); err == nil {
+			l.remove(int(id))
+		}
+	}
+	return nil
+}
+
+func (l *eventLoop) remove(id int) {
+	t, ok := l.timers[id]
+	if !ok {
+		return
+	}
+	delete(l.timers, id)
+	l.release(t)
+}
+
+// release lets the garbage collector reclaim the values held by t.
+func (l *eventLoop) release(t *timer) {
+	t.fn.ToValue().Unprotect()
+	for _, arg := range t.args {
+		arg.Unprotect()
+	}
+}
+
+// pending reports whether any timers are scheduled.
+func (l *eventLoop) pending() bool {
+	return l != nil && len(l.timers) > 0
+}
+
+// next returns the time at which the earliest timer is due.
+func (l *eventLoop) next() (time.Time, bool) {
+	var when time.Time
+	found := false
+	for _, t := range l.timers {
+		if !found || t.when.Before(when) {
+			when, found = t.when, true
+		}
+	}
+	return when, found
+}
+
+// runDue calls every timer that is due, in the order they were scheduled.
+func (l *eventLoop) runDue() {
+	now := time.Now()
+	var due []*timer
+	for _, t := range l.timers {
+		if !t.when.After(now) {
+			due = append(due, t)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].when.Equal(due[j].when) {
+			return due[i].id < due[j].id
+		}
+		return due[i].when.Before(due[j].when)
+	})
+
+	for _, t := range due {
+		// An earlier callback may have cleared this timer.
+		if _, ok := l.timers[t.id]; !ok {
+			continue
+		}
+		if t.interval > 0 {
+			t.when = now.Add(t.interval)
+		} else {
+			delete(l.timers, t.id)
+		}
+		_, err := t.fn.Call(nil, t.args...)
+		if err != nil {
+			l.onError(err)
+		}
+		if t.interval == 0 {
+			l.release(t)
+		}
+	}
+}
+
+// drain runs timers until none are left.
+func (l *eventLoop) drain() {
+	for l.pending() {
+		if when, ok := l.next(); ok {
+			time.Sleep(time.Until(when))
+		}
+		l.runDue()
+	}
+}
@@ -0,0 +1,141 @@
+package gojs
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// Numberer is implemented by Go values whose native objects convert to a
+// number, as in arithmetic or Number(obj).
+type Numberer interface {
+	JSNumber() float64
+}
+
+// JSONer is implemented by Go values that choose what JSON.stringify
+// serialises for their native objects in place of their encoding/json
+// form. The result is converted as by NewValue.
+type JSONer interface {
+	JSON() interface{}
+}
+
+// toNumber converts the Go value behind a native object to a number, using
+// JSNumber if it has one and otherwise its underlying kind, as for a named
+// type such as type Celsius float64. It reports false if neither applies,
+// and returns a *RangeError for integers beyond Number.MAX_SAFE_INTEGER.
+func (data *object_data) toNumber() (float64, bool, error) {
+	if n, ok := data.interfaceValue().(Numberer); ok {
+		return n.JSNumber(), true, nil
+	}
+	return underlyingNumber(data.elem())
+}
+
+// toString converts the Go value behind a native object to a string. It
+// tries String, then MarshalText, then the underlying kind of the value,
+// and finally falls back to a tag naming the Go type, as in
+// "[object main.Person]".
+func (data *object_data) toString(ctx *Context) string {
+	switch v := data.interfaceValue().(type) {
+	case Stringer:
+		return v.String()
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	val := data.elem()
+	switch val.Kind() {
+	case reflect.String:
+		return val.String()
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(val.Uint(), 10)
+	}
+	if num, ok, _ := underlyingNumber(val); ok {
+		if str, err := ctx.NewNumberValue(num).ToString(); err == nil {
+			return str
+		}
+	}
+	return "[object " + goTypeName(data.typ) + "]"
+}
+
+// interfaceValue returns the Go value behind a native object, or nil if it
+// cannot be had.
+func (data *object_data) interfaceValue() interface{} {
+	if !data.val.IsValid() || !data.val.CanInterface() {
+		return nil
+	}
+	return data.val.Interface()
+}
+
+// underlyingNumber converts val to a number if its kind is numeric or
+// boolean. Integers follow Int64AsNumber, failing with a *RangeError beyond
+// Number.MAX_SAFE_INTEGER.
+func underlyingNumber(val reflect.Value) (float64, bool, error) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		num, _, _, err := intConversion(val, Int64AsNumber)
+		return num, true, err
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true, nil
+	case reflect.Bool:
+		if val.Bool() {
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+	return 0, false, nil
+}
+
+// goTypeName names typ, with pointers followed, as in "main.Person".
+func goTypeName(typ reflect.Type) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.String()
+}
+
+// toJSON returns the value JSON.stringify serialises for the Go value
+// behind a native object: the result of its JSON method if it has one, and
+// otherwise the value as encoding/json marshals it, honouring json tags and
+// MarshalJSON. Values encoding/json cannot represent, such as functions and
+// channels, give undefined, as functions do in JavaScript.
+func (data *object_data) toJSON(ctx *Context) (*Value, error) {
+	v := data.interfaceValue()
+	if j, ok := v.(JSONer); ok {
+		return ctx.NewValue(j.JSON()), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		var unsupported *json.UnsupportedTypeError
+		if errors.As(err, &unsupported) {
+			return ctx.NewUndefinedValue(), nil
+		}
+		return nil, &TypeError{"gojs: toJSON: " + err.Error()}
+	}
+	return ctx.ParseJSON(b)
+}
+
+// toJSONCallback is the toJSON method placed on the prototypes of native
+// objects.
+func toJSONCallback(ctx *Context, _, this *Object, _ []*Value) *Value {
+	var data *object_data
+	if this != nil {
+		data = ctx.nativeObjectData(this.ToValue())
+	}
+	if data == nil {
+		panic(&TypeError{"gojs: toJSON called on an object that is not a Go value"})
+	}
+	ret, err := data.toJSON(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
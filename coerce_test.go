@@ -0,0 +1,145 @@
+package gojs
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type coerce_money struct {
+	Cents int
+}
+
+func (m coerce_money) JSNumber() float64 {
+	return float64(m.Cents) / 100
+}
+
+func (m coerce_money) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("$%d.%02d", m.Cents/100, m.Cents%100)), nil
+}
+
+type coerce_person struct {
+	Name string
+}
+
+type coerce_point struct {
+	X, Y int
+}
+
+func (p coerce_point) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]int{p.X, p.Y})
+}
+
+type coerce_id int64
+
+// coerce_broken panics in its conversion hooks.
+type coerce_broken struct{ n *int }
+
+func (b coerce_broken) String() string    { return fmt.Sprint(*b.n) }
+func (b coerce_broken) JSNumber() float64 { return float64(*b.n) }
+
+type coerce_tagged struct {
+	Secret string
+}
+
+func (t coerce_tagged) JSON() interface{} {
+	return "redacted"
+}
+
+func TestNativeObjectCoercion(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	global := ctx.GlobalObject()
+	global.Set("money", ctx.NewNativeObject(coerce_money{250}).ToValue())
+	global.Set("person", ctx.NewNativeObject(&coerce_person{"Ann"}).ToValue())
+	global.Set("point", ctx.NewNativeObject(coerce_point{1, 2}).ToValue())
+	global.Set("tagged", ctx.NewNativeObject(coerce_tagged{"x"}).ToValue())
+	global.Set("small", ctx.NewNativeObject(coerce_id(42)).ToValue())
+	global.Set("huge", ctx.NewNativeObject(coerce_id(1<<60+1)).ToValue())
+	global.Set("broken", ctx.NewNativeObject(coerce_broken{}).ToValue())
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"money * 2", "5"},
+		{"String(money)", "$2.50"},
+		{"String(person)", "[object gojs.coerce_person]"},
+		{"Object.prototype.toString.call(person)", "[object gojs.coerce_person]"},
+		{"isNaN(+person)", "true"},
+		{"JSON.stringify(point)", "[1,2]"},
+		{"JSON.stringify({p: point, t: tagged})", `{"p":[1,2],"t":"redacted"}`},
+		{"JSON.stringify(person)", `{"Name":"Ann"}`},
+		{"small * 2", "84"},
+		{"String(huge)", "1152921504606846977"},
+		{"try { +huge } catch (e) { e.name }", "RangeError"},
+		{"try { String(broken) } catch (e) { 'threw' }", "threw"},
+		{"try { +broken } catch (e) { 'threw' }", "threw"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.ParseJSON([]byte(`{"a": [1, "two"]}`))
+	if err != nil {
+		t.Fatalf("ctx.ParseJSON failed: %v", err)
+	}
+	if b, err := v.JSON(); err != nil || string(b) != `{"a":[1,"two"]}` {
+		t.Errorf("ctx.ParseJSON gave %s, %v", b, err)
+	}
+	if _, err := ctx.ParseJSON([]byte("{")); err == nil {
+		t.Errorf("ctx.ParseJSON did not fail on invalid JSON")
+	}
+}
+
+type coerce_record struct {
+	ID      int               `json:"id"`
+	Tags    []string          `json:"tags,omitempty"`
+	Point   coerce_point      `json:"point"`
+	Extra   map[string]string `json:"-"`
+	Private string
+	hidden  int
+}
+
+func TestNativeObjectToJSON(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	rec := &coerce_record{ID: 7, Point: coerce_point{3, 4}, Extra: map[string]string{"a": "b"}, Private: "p", hidden: 1}
+	global := ctx.GlobalObject()
+	global.Set("rec", ctx.NewNativeObject(rec).ToValue())
+	global.Set("fn", ctx.NewNativeObject(&struct{ F func() }{}).ToValue())
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"JSON.stringify(rec)", `{"id":7,"point":[3,4],"Private":"p"}`},
+		{"rec.Tags = ['x']; JSON.stringify(rec.toJSON().tags)", `["x"]`},
+		{"JSON.stringify([rec.Point])", "[[3,4]]"},
+		{"JSON.stringify({fn: fn})", "{}"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
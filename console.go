@@ -0,0 +1,446 @@
+package gojs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ConsoleLevel identifies the console method that produced a message.
+type ConsoleLevel int
+
+const (
+	ConsoleLog ConsoleLevel = iota
+	ConsoleInfo
+	ConsoleWarn
+	ConsoleError
+	ConsoleDebug
+	ConsoleTrace
+)
+
+func (l ConsoleLevel) String() string {
+	switch l {
+	case ConsoleLog:
+		return "log"
+	case ConsoleInfo:
+		return "info"
+	case ConsoleWarn:
+		return "warn"
+	case ConsoleError:
+		return "error"
+	case ConsoleDebug:
+		return "debug"
+	case ConsoleTrace:
+		return "trace"
+	}
+	return "ConsoleLevel(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ConsoleSink receives the formatted output of the console object installed
+// by InstallConsole.
+type ConsoleSink interface {
+	Emit(level ConsoleLevel, message string)
+}
+
+type writerConsoleSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterConsoleSink returns a ConsoleSink that writes each message to w
+// followed by a newline.
+func NewWriterConsoleSink(w io.Writer) ConsoleSink {
+	return &writerConsoleSink{w: w}
+}
+
+func (s *writerConsoleSink) Emit(level ConsoleLevel, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(s.w, message+"\n")
+}
+
+type slogConsoleSink struct {
+	h slog.Handler
+}
+
+// NewSlogConsoleSink returns a ConsoleSink that forwards messages to h.
+// console.log and console.info are logged at slog.LevelInfo, console.debug
+// and console.trace at slog.LevelDebug.
+func NewSlogConsoleSink(h slog.Handler) ConsoleSink {
+	return &slogConsoleSink{h}
+}
+
+func (s *slogConsoleSink) Emit(level ConsoleLevel, message string) {
+	var l slog.Level
+	switch level {
+	case ConsoleWarn:
+		l = slog.LevelWarn
+	case ConsoleError:
+		l = slog.LevelError
+	case ConsoleDebug, ConsoleTrace:
+		l = slog.LevelDebug
+	default:
+		l = slog.LevelInfo
+	}
+
+	bg := context.Background()
+	if !s.h.Enabled(bg, l) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), l, message, 0)
+	r.AddAttrs(slog.String("console", level.String()))
+	s.h.Handle(bg, r)
+}
+
+// ConsoleEntry is a single message captured by a ConsoleRecorder.
+type ConsoleEntry struct {
+	Level   ConsoleLevel
+	Message string
+}
+
+// ConsoleRecorder is a ConsoleSink that keeps every message in memory. It is
+// mostly useful in tests.
+type ConsoleRecorder struct {
+	mu      sync.Mutex
+	entries []ConsoleEntry
+}
+
+func (r *ConsoleRecorder) Emit(level ConsoleLevel, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, ConsoleEntry{level, message})
+}
+
+// Entries returns a copy of the messages recorded so far.
+func (r *ConsoleRecorder) Entries() []ConsoleEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ConsoleEntry(nil), r.entries...)
+}
+
+// Reset discards all recorded messages.
+func (r *ConsoleRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+//=========================================================
+// console global
+//---------------------------------------------------------
+
+type console struct {
+	sink ConsoleSink
+
+	mu     sync.Mutex
+	timers map[string]time.Time
+}
+
+// InstallConsole defines a global console object whose methods format their
+// arguments the way browsers do and send the result to sink.
+//
+// The supported methods are log, info, warn, error, debug, trace, time,
+// timeEnd, assert and table.
+func (ctx *Context) InstallConsole(sink ConsoleSink) error {
+	c := &console{sink: sink, timers: make(map[string]time.Time)}
+
+	methods := map[string]GoFunctionCallback{
+		"log":     c.level(ConsoleLog),
+		"info":    c.level(ConsoleInfo),
+		"warn":    c.level(ConsoleWarn),
+		"error":   c.level(ConsoleError),
+		"debug":   c.level(ConsoleDebug),
+		"trace":   c.trace,
+		"time":    c.time,
+		"timeEnd": c.timeEnd,
+		"assert":  c.assert,
+		"table":   c.table,
+	}
+
+	obj := ctx.NewEmptyObject()
+	for name, method := range methods {
+		err := obj.SetWithAttributes(name, ctx.NewFunctionWithCallback(method).ToValue(), PropertyAttributeDontEnum)
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.GlobalObject().SetWithAttributes("console", obj.ToValue(), PropertyAttributeDontEnum)
+}
+
+func (c *console) level(level ConsoleLevel) GoFunctionCallback {
+	return func(ctx *Context, _, _ *Object, args []*Value) *Value {
+		c.sink.Emit(level, c.format(ctx, args))
+		return nil
+	}
+}
+
+func (c *console) trace(ctx *Context, _, _ *Object, args []*Value) *Value {
+	msg := "Trace"
+	if len(args) > 0 {
+		msg += ": " + c.format(ctx, args)
+	}
+	if e, err := ctx.NewError(""); err == nil {
+		if stack, err := e.Get("stack"); err == nil && stack.IsString() {
+			msg += "\n" + stack.ToStringOrDie()
+		}
+	}
+	c.sink.Emit(ConsoleTrace, msg)
+	return nil
+}
+
+func consoleLabel(ctx *Context, args []*Value) string {
+	if len(args) == 0 || args[0].IsUndefined() {
+		return "default"
+	}
+	return args[0].ToStringOrDie()
+}
+
+func (c *console) time(ctx *Context, _, _ *Object, args []*Value) *Value {
+	label := consoleLabel(ctx, args)
+
+	c.mu.Lock()
+	_, exists := c.timers[label]
+	if !exists {
+		c.timers[label] = time.Now()
+	}
+	c.mu.Unlock()
+
+	if exists {
+		c.sink.Emit(ConsoleWarn, fmt.Sprintf("Timer '%s' already exists", label))
+	}
+	return nil
+}
+
+func (c *console) timeEnd(ctx *Context, _, _ *Object, args []*Value) *Value {
+	label := consoleLabel(ctx, args)
+
+	c.mu.Lock()
+	start, exists := c.timers[label]
+	delete(c.timers, label)
+	c.mu.Unlock()
+
+	if !exists {
+		c.sink.Emit(ConsoleWarn, fmt.Sprintf("Timer '%s' does not exist", label))
+		return nil
+	}
+	ms := float64(time.Since(start)) / float64(time.Millisecond)
+	c.sink.Emit(ConsoleLog, fmt.Sprintf("%s: %.3fms", label, ms))
+	return nil
+}
+
+func (c *console) assert(ctx *Context, _, _ *Object, args []*Value) *Value {
+	if len(args) > 0 && args[0].ToBoolean() {
+		return nil
+	}
+	msg := "Assertion failed"
+	if len(args) > 1 {
+		msg += ": " + c.format(ctx, args[1:])
+	}
+	c.sink.Emit(ConsoleError, msg)
+	return nil
+}
+
+// format joins args the way console.log does, applying printf-style
+// substitutions when the first argument is a string.
+func (c *console) format(ctx *Context, args []*Value) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	var parts []string
+	rest := args
+	if args[0].IsString() {
+		var first string
+		first, rest = c.substitute(ctx, args[0].ToStringOrDie(), args[1:])
+		parts = append(parts, first)
+	}
+	for _, arg := range rest {
+		if arg.IsString() {
+			parts = append(parts, arg.ToStringOrDie())
+		} else {
+			parts = append(parts, arg.Inspect(nil))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	// consoleStringOptions are used by %s to show objects briefly.
+	consoleStringOptions = InspectOptions{Depth: 0, MaxArrayLength: 100, BreakLength: 80}
+
+	// consoleObjectOptions are used by %o to show objects in detail.
+	consoleObjectOptions = InspectOptions{Depth: 4, ShowHidden: true, MaxArrayLength: 100, BreakLength: 80}
+)
+
+// substitute replaces the %s, %d, %i, %f, %o, %O, %c and %% directives in
+// format with args, and returns the arguments that were not consumed.
+func (c *console) substitute(ctx *Context, format string, args []*Value) (string, []*Value) {
+	if !strings.Contains(format, "%") {
+		return format, args
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		verb := format[i+1]
+		if verb == '%' {
+			b.WriteByte('%')
+			i++
+			continue
+		}
+		if len(args) == 0 || !strings.ContainsRune("sdifoOc", rune(verb)) {
+			b.WriteByte('%')
+			continue
+		}
+		arg := args[0]
+		args = args[1:]
+		i++
+
+		switch verb {
+		case 's':
+			if arg.IsObject() || arg.IsSymbol() {
+				b.WriteString(arg.Inspect(&consoleStringOptions))
+			} else {
+				b.WriteString(arg.ToStringOrDie())
+			}
+		case 'd', 'i':
+			num, err := arg.ToNumber()
+			if err != nil || math.IsNaN(num) || arg.IsObject() {
+				b.WriteString("NaN")
+			} else {
+				b.WriteString(inspectNumber(math.Trunc(num)))
+			}
+		case 'f':
+			num, err := arg.ToNumber()
+			if err != nil || arg.IsObject() {
+				b.WriteString("NaN")
+			} else {
+				b.WriteString(inspectNumber(num))
+			}
+		case 'o':
+			b.WriteString(arg.Inspect(&consoleObjectOptions))
+		case 'O':
+			b.WriteString(arg.Inspect(nil))
+		case 'c':
+			// CSS styling has no meaning outside of a browser.
+		}
+	}
+	return b.String(), args
+}
+
+//=========================================================
+// console.table
+//---------------------------------------------------------
+
+func (c *console) table(ctx *Context, _, _ *Object, args []*Value) *Value {
+	if len(args) == 0 || !args[0].IsObject() {
+		c.sink.Emit(ConsoleLog, c.format(ctx, args))
+		return nil
+	}
+	data := args[0].ToObjectOrDie()
+
+	header := []string{"(index)"}
+	columns := map[string]int{}
+	hasValues := false
+	var rows []map[string]string
+	var index []string
+
+	for _, name := range data.Keys() {
+		item, err := data.Get(name)
+		if err != nil {
+			continue
+		}
+		row := map[string]string{}
+		if item.IsObject() && !item.IsFunction() {
+			obj := item.ToObjectOrDie()
+			for _, field := range obj.Keys() {
+				fv, err := obj.Get(field)
+				if err != nil {
+					continue
+				}
+				if _, ok := columns[field]; !ok {
+					columns[field] = len(header)
+					header = append(header, field)
+				}
+				row[field] = fv.Inspect(nil)
+			}
+		} else {
+			hasValues = true
+			row["\x00values"] = item.Inspect(nil)
+		}
+		index = append(index, name)
+		rows = append(rows, row)
+	}
+
+	keys := header[1:]
+	if hasValues {
+		header = append(header, "Values")
+	}
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = append(cells[i], index[i])
+		for _, key := range keys {
+			cells[i] = append(cells[i], row[key])
+		}
+		if hasValues {
+			cells[i] = append(cells[i], row["\x00values"])
+		}
+	}
+
+	c.sink.Emit(ConsoleLog, renderConsoleTable(header, cells))
+	return nil
+}
+
+func renderConsoleTable(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h) + 2
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell) + 2; w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	line := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+	center := func(s string, w int) string {
+		pad := w - utf8.RuneCountInString(s)
+		return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2)
+	}
+	render := func(row []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			parts[i] = center(cell, w)
+		}
+		return "│" + strings.Join(parts, "│") + "│"
+	}
+
+	out := []string{line("┌", "┬", "┐"), render(header), line("├", "┼", "┤")}
+	for _, row := range rows {
+		out = append(out, render(row))
+	}
+	out = append(out, line("└", "┴", "┘"))
+	return strings.Join(out, "\n")
+}
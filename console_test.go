@@ -0,0 +1,122 @@
+package gojs
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestInstallConsole(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	rec := new(ConsoleRecorder)
+	if err := ctx.InstallConsole(rec); err != nil {
+		t.Fatalf("ctx.InstallConsole returned an error (%v)", err)
+	}
+
+	tests := []struct {
+		script string
+		level  ConsoleLevel
+		want   string
+	}{
+		{"console.log('hello', 'world')", ConsoleLog, "hello world"},
+		{"console.info(1, true, null, undefined)", ConsoleInfo, "1 true null undefined"},
+		{"console.warn('%s is %d years', 'Bob', 42.9)", ConsoleWarn, "Bob is 42 years"},
+		{"console.error('%f%%', 1.5)", ConsoleError, "1.5%"},
+		{"console.debug('%c styled', 'color: red')", ConsoleDebug, " styled"},
+		{"console.log('extra', 'args', 1)", ConsoleLog, "extra args 1"},
+		{"console.log('%O', {a: 1, b: 'x', c: [1, 2]})", ConsoleLog, "{ a: 1, b: 'x', c: [ 1, 2 ] }"},
+		{"console.log('%o', [1])", ConsoleLog, "[ 1, [length]: 1 ]"},
+		{"console.log('%s', {a: {b: 1}})", ConsoleLog, "{ a: [Object] }"},
+		{"console.log({})", ConsoleLog, "{}"},
+		{"console.log([])", ConsoleLog, "[]"},
+		{"console.log(function foo() {})", ConsoleLog, "[Function: foo]"},
+		{"var o = {}; o.self = o; console.log(o)", ConsoleLog, "<ref *1> { self: [Circular *1] }"},
+		{"console.log({a: {b: {c: {d: 1}}}})", ConsoleLog, "{ a: { b: { c: [Object] } } }"},
+		{"console.assert(true, 'not shown'); console.assert(false, 'x=%d', 3)", ConsoleError, "Assertion failed: x=3"},
+	}
+
+	for _, test := range tests {
+		rec.Reset()
+		if _, err := ctx.EvaluateScript(test.script, nil, "./console_test.go", 1); err != nil {
+			t.Errorf("%s: ctx.EvaluateScript returned an error (%v)", test.script, err)
+			continue
+		}
+		entries := rec.Entries()
+		if len(entries) != 1 {
+			t.Errorf("%s: want 1 console message, got %d (%v)", test.script, len(entries), entries)
+			continue
+		}
+		if entries[0].Level != test.level {
+			t.Errorf("%s: want level %v, got %v", test.script, test.level, entries[0].Level)
+		}
+		if entries[0].Message != test.want {
+			t.Errorf("%s: want message %q, got %q", test.script, test.want, entries[0].Message)
+		}
+	}
+}
+
+func TestConsoleTimeAndTable(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	rec := new(ConsoleRecorder)
+	if err := ctx.InstallConsole(rec); err != nil {
+		t.Fatalf("ctx.InstallConsole returned an error (%v)", err)
+	}
+
+	_, err := ctx.EvaluateScript("console.time('t'); console.timeEnd('t'); console.timeEnd('t')", nil, "./console_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 console messages, got %d (%v)", len(entries), entries)
+	}
+	if !strings.HasPrefix(entries[0].Message, "t: ") || !strings.HasSuffix(entries[0].Message, "ms") {
+		t.Errorf("console.timeEnd printed %q", entries[0].Message)
+	}
+	if entries[1].Level != ConsoleWarn {
+		t.Errorf("console.timeEnd on a missing timer did not warn (%v)", entries[1])
+	}
+
+	rec.Reset()
+	_, err = ctx.EvaluateScript("console.table([{a: 1, b: 'y'}, {a: 2}])", nil, "./console_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	want := strings.Join([]string{
+		"┌─────────┬───┬─────┐",
+		"│ (index) │ a │  b  │",
+		"├─────────┼───┼─────┤",
+		"│    0    │ 1 │ 'y' │",
+		"│    1    │ 2 │     │",
+		"└─────────┴───┴─────┘",
+	}, "\n")
+	if entries := rec.Entries(); len(entries) != 1 || entries[0].Message != want {
+		t.Errorf("console.table printed %q, want %q", entries, want)
+	}
+}
+
+func TestConsoleSinks(t *testing.T) {
+	var buf bytes.Buffer
+	NewWriterConsoleSink(&buf).Emit(ConsoleLog, "to writer")
+	if buf.String() != "to writer\n" {
+		t.Errorf("writer sink wrote %q", buf.String())
+	}
+
+	buf.Reset()
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	sink := NewSlogConsoleSink(h)
+	sink.Emit(ConsoleDebug, "hidden")
+	sink.Emit(ConsoleWarn, "shown")
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("slog sink logged a message below the handler's level: %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "msg=shown") || !strings.Contains(out, "console=warn") {
+		t.Errorf("slog sink logged %q", out)
+	}
+}
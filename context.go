@@ -3,7 +3,13 @@ package gojs
 // #include <stdlib.h>
 // #include <JavaScriptCore/JSContextRef.h>
 import "C"
-import "unsafe"
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
 
 // Context wraps a JavaScriptCore JSContextRef.
 type Context struct {
@@ -13,15 +19,103 @@ type Context struct {
 // GlobalContext wraps a JavaScriptCore JSGlobalContextRef.
 type GlobalContext Context
 
+// contextState holds the Go-side state attached to a global context. Native
+// callbacks receive a fresh *Context for every call, so anything that must
+// outlive a single call is kept here, keyed by the global context.
+type contextState struct {
+	// refs counts the references taken through NewContext and Retain. The
+	// state is dropped when the count falls back to zero.
+	refs int
+
+	tracer     atomic.Pointer[tracer]
+	terminated atomic.Bool
+
+	mu           sync.Mutex
+	helpers      map[string]*Object
+	crossContext CrossContextPolicy
+	convert      ConvertOptions
+	iterable     *Object
+	keys         map[string]*Key
+	prototypes   map[reflect.Type]*Object
+}
+
+var (
+	contextsMu sync.Mutex
+	contexts   = make(map[C.JSGlobalContextRef]*contextState)
+)
+
+// state returns the contextState for the global context of ctx, creating it
+// if needed.
+func (ctx *Context) state() *contextState {
+	global := C.JSContextGetGlobalContext(ctx.ref)
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	s := contexts[global]
+	if s == nil {
+		s = new(contextState)
+		contexts[global] = s
+	}
+	return s
+}
+
+// helper returns the JavaScript function defined by the expression src,
+// evaluating it the first time it is needed in this context. Helpers give
+// gojs access to built-ins that the JavaScriptCore C API does not expose.
+// They capture the built-ins they use when evaluated, by taking them as
+// arguments of an enclosing function, so that they keep working as they did
+// when first used if a script later replaces those built-ins. They are
+// protected from garbage collection until the context is released.
+func (ctx *Context) helper(src string) (*Object, error) {
+	s := ctx.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fn := s.helpers[src]; fn != nil {
+		return ctx.newObject(fn.ref), nil
+	}
+
+	ret, err := ctx.EvaluateScript("("+src+")", nil, "", 1)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := ret.ToObject()
+	if err != nil {
+		return nil, err
+	}
+	ret.Protect()
+	if s.helpers == nil {
+		s.helpers = make(map[string]*Object)
+	}
+	s.helpers[src] = fn
+	return fn, nil
+}
+
+// callHelper calls the helper defined by src with args.
+func (ctx *Context) callHelper(src string, args ...*Value) (*Value, error) {
+	fn, err := ctx.helper(src)
+	if err != nil {
+		return nil, err
+	}
+	return fn.Call(nil, args...)
+}
+
 func NewContext() *Context {
 	c_nil := unsafe.Pointer(uintptr(0))
 
 	ctx := new(Context)
 
 	ctx.ref = C.JSContextRef(C.JSGlobalContextCreate((C.JSClassRef)(c_nil)))
+	ctx.created()
 	return ctx
 }
 
+// created takes the first reference to the state of a newly created global
+// context.
+func (ctx *Context) created() {
+	ctx.state().refs++
+	debugCreated(ctx.ref)
+}
+
 type RawContext C.JSContextRef
 
 type RawGlobalContext C.JSGlobalContextRef
@@ -40,12 +134,91 @@ func NewGlobalContextFrom(raw RawGlobalContext) *GlobalContext {
 
 func (ctx *Context) Retain() {
 	C.JSGlobalContextRetain(ctx.ref)
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	if s := contexts[C.JSContextGetGlobalContext(ctx.ref)]; s != nil {
+		s.refs++
+	}
 }
 
 func (ctx *Context) Release() {
+	ctx.checkLive("Context.Release")
+	global := C.JSContextGetGlobalContext(ctx.ref)
+
+	var dropped *contextState
+	contextsMu.Lock()
+	if s := contexts[global]; s != nil {
+		s.refs--
+		if s.refs <= 0 {
+			if s.tracer.Load() != nil {
+				atomic.AddInt32(&activeTracers, -1)
+			}
+			if s.terminated.Load() {
+				atomic.AddInt32(&terminatedContexts, -1)
+			}
+			delete(contexts, global)
+			debugReleased(ctx.ref)
+			dropped = s
+		}
+	}
+	contextsMu.Unlock()
+
+	if dropped != nil {
+		dropped.mu.Lock()
+		// Let the collector have the objects cached for the context.
+		for _, fn := range dropped.helpers {
+			C.JSValueUnprotect(ctx.ref, C.JSValueRef(unsafe.Pointer(fn.ref)))
+		}
+		for _, proto := range dropped.prototypes {
+			C.JSValueUnprotect(ctx.ref, C.JSValueRef(unsafe.Pointer(proto.ref)))
+		}
+		if dropped.iterable != nil {
+			C.JSValueUnprotect(ctx.ref, C.JSValueRef(unsafe.Pointer(dropped.iterable.ref)))
+		}
+		dropped.helpers, dropped.prototypes, dropped.iterable = nil, nil, nil
+		// Keys outlive the context; their finalizers release the strings.
+		dropped.keys = nil
+		dropped.mu.Unlock()
+	}
+
 	C.JSGlobalContextRelease(ctx.ref)
 }
 
+// ErrTerminated is returned by EvaluateScript, and thrown to scripts that
+// call into Go, once the context has been terminated.
+var ErrTerminated = errors.New("gojs: context terminated")
+
+// terminatedContexts counts the contexts that have been terminated, so that
+// callbacks from the others cost a single atomic load to check.
+var terminatedContexts int32
+
+// Terminate stops the host serving scripts in the global context of ctx.
+// From then on, every call a script makes into Go throws ErrTerminated and
+// EvaluateScript fails with it. JavaScriptCore offers no way to abort a
+// running script, so code that catches the exception runs on, but it can
+// no longer reach the host.
+func (ctx *Context) Terminate() {
+	if !ctx.state().terminated.Swap(true) {
+		atomic.AddInt32(&terminatedContexts, 1)
+	}
+}
+
+// Terminated reports whether Terminate has been called for the global
+// context of ctx.
+func (ctx *Context) Terminated() bool {
+	return atomic.LoadInt32(&terminatedContexts) != 0 && ctx.state().terminated.Load()
+}
+
+// checkTerminated panics with ErrTerminated if the context has been
+// terminated. Native callbacks call it inside their recover, so that the
+// panic is thrown to the script.
+func (ctx *Context) checkTerminated() {
+	if ctx.Terminated() {
+		panic(ErrTerminated)
+	}
+}
+
 func (ctx *Context) GlobalObject() *Object {
 	ret := C.JSContextGetGlobalObject(ctx.ref)
 	return ctx.newObject(ret)
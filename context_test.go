@@ -29,3 +29,36 @@ func TestContextGlobalObject(t *testing.T) {
 		t.Errorf("ctx.GlobalObject() did not return a javascript object")
 	}
 }
+
+func TestContextTerminate(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	calls := 0
+	fn := ctx.NewFunctionWithCallback(func(ctx *Context, _, _ *Object, _ []*Value) *Value {
+		calls++
+		ctx.Terminate()
+		return nil
+	})
+	ctx.GlobalObject().Set("stop", fn.ToValue())
+
+	ret, err := ctx.EvaluateScript("var caught; stop(); try { stop() } catch (e) { caught = String(e) } caught", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if calls != 1 || ret.String() != ErrTerminated.Error() {
+		t.Errorf("after Terminate, the callback ran %d times and threw %q", calls, ret.String())
+	}
+	if !ctx.Terminated() {
+		t.Errorf("ctx.Terminated() returned false after Terminate")
+	}
+	if _, err := ctx.EvaluateScript("1", nil, "", 1); err != ErrTerminated {
+		t.Errorf("ctx.EvaluateScript returned %v after Terminate, want ErrTerminated", err)
+	}
+
+	other := NewContext()
+	defer other.Release()
+	if other.Terminated() {
+		t.Errorf("a new context is terminated")
+	}
+}
@@ -0,0 +1,1029 @@
+package gojs
+
+// #include <stdlib.h>
+// #include "bulk.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// tape is the flattened form of a tree of JavaScript values, as described in
+// bulk.h. Converting through a tape takes one call into JavaScriptCore for
+// the whole tree, rather than one or more for every value in it.
+type tape struct {
+	nodes []C.gojs_node
+	chars []uint16
+
+	// opts are the ConvertOptions encode follows, and bigints, maps and
+	// sets record whether it produced any BigInts, Maps and Sets.
+	opts                ConvertOptions
+	bigints, maps, sets bool
+
+	// visiting holds the pointers, maps and slices being encoded, to
+	// detect values that contain themselves.
+	visiting map[visit]bool
+}
+
+// visit identifies a pointer, map or slice by its address, and for slices
+// their length, as encoding/json does.
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// flatten converts v and everything reachable from it to a tape.
+func (ctx *Context) flatten(v *Value) (*tape, error) {
+	var builtins C.gojs_builtins
+	for _, b := range []struct {
+		ref *C.JSObjectRef
+		src string
+	}{
+		{&builtins.isArray, "(function (isArray) { return function (v) { return isArray(v); }; })(Array.isArray)"},
+		{&builtins.date, "Date"},
+		// JavaScriptCore versions without Map and Set get constructors that
+		// nothing is an instance of.
+		{&builtins._map, "typeof Map === 'function' ? Map : function () {}"},
+		{&builtins.set, "typeof Set === 'function' ? Set : function () {}"},
+		{&builtins.entries, "(function (from) { return function (c) { return from(c); }; })(Array.from)"},
+		{&builtins.bigint, "(function (String) { return function (v) { return typeof v === 'bigint' ? String(v) : undefined; }; })(String)"},
+	} {
+		fn, err := ctx.helper(b.src)
+		if err != nil {
+			return nil, err
+		}
+		*b.ref = fn.ref
+	}
+
+	var nodes *C.gojs_node
+	var chars *C.JSChar
+	var nodeCount, charCount C.size_t
+	errVal := ctx.newErrorValue()
+	ret := C.gojs_flatten(ctx.ref, v.ref, &builtins, &nodes, &nodeCount, &chars, &charCount, &errVal.ref)
+	defer C.free(unsafe.Pointer(nodes))
+	defer C.free(unsafe.Pointer(chars))
+	switch ret {
+	case C.GOJS_OK:
+		t := &tape{
+			nodes: make([]C.gojs_node, nodeCount),
+			chars: make([]uint16, charCount),
+		}
+		copy(t.nodes, unsafe.Slice(nodes, nodeCount))
+		copy(t.chars, unsafe.Slice((*uint16)(unsafe.Pointer(chars)), charCount))
+		return t, nil
+	case C.GOJS_NO_MEMORY:
+		return nil, errors.New("gojs: out of memory converting a value")
+	case C.GOJS_CYCLIC:
+		return nil, errors.New("gojs: cannot convert a value that contains itself")
+	case C.GOJS_TOO_DEEP:
+		return nil, fmt.Errorf("gojs: cannot convert a value nested more than %d levels deep", C.GOJS_MAX_DEPTH)
+	case C.GOJS_TOO_LONG:
+		return nil, fmt.Errorf("gojs: cannot convert an array, Map or Set of more than %d elements", C.GOJS_MAX_LENGTH)
+	default:
+		return nil, errVal
+	}
+}
+
+func (t *tape) str(n *C.gojs_node) string {
+	return utf16ToString(t.chars[n.offset : n.offset+n.len])
+}
+
+// skip returns the index of the node after the value starting at i.
+func (t *tape) skip(i int) int {
+	n := &t.nodes[i]
+	i++
+	switch n.kind {
+	case C.GOJS_ARRAY, C.GOJS_SET:
+		for j := 0; j < int(n.len); j++ {
+			i = t.skip(i)
+		}
+	case C.GOJS_OBJECT:
+		for j := 0; j < int(n.len); j++ {
+			i = t.skip(i + 1)
+		}
+	case C.GOJS_MAP:
+		for j := 0; j < int(n.len); j++ {
+			i = t.skip(t.skip(i))
+		}
+	}
+	return i
+}
+
+// value returns the generic Go form of the value starting at i, and the
+// index of the node after it. Maps become map[interface{}]interface{}, which
+// fails for keys that are arrays or objects, and Sets become []interface{}.
+func (t *tape) value(i int) (interface{}, int, error) {
+	n := &t.nodes[i]
+	i++
+	var err error
+	switch n.kind {
+	case C.GOJS_BOOLEAN:
+		return n.number != 0, i, nil
+	case C.GOJS_NUMBER:
+		return float64(n.number), i, nil
+	case C.GOJS_STRING:
+		return t.str(n), i, nil
+	case C.GOJS_BIGINT:
+		x, err := t.bigInt(n)
+		return x, i, err
+	case C.GOJS_DATE:
+		if date, ok := dateTime(float64(n.number)); ok {
+			return date, i, nil
+		}
+		return nil, i, nil
+	case C.GOJS_ARRAY, C.GOJS_SET:
+		arr := make([]interface{}, n.len)
+		for j := range arr {
+			if arr[j], i, err = t.value(i); err != nil {
+				return nil, 0, err
+			}
+		}
+		return arr, i, nil
+	case C.GOJS_OBJECT:
+		obj := make(map[string]interface{}, n.len)
+		for j := 0; j < int(n.len); j++ {
+			key := t.str(&t.nodes[i])
+			if obj[key], i, err = t.value(i + 1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return obj, i, nil
+	case C.GOJS_MAP:
+		m := make(map[interface{}]interface{}, n.len)
+		for j := 0; j < int(n.len); j++ {
+			var key, val interface{}
+			if key, i, err = t.value(i); err != nil {
+				return nil, 0, err
+			}
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return nil, 0, errors.New("gojs: cannot convert a Map with object keys")
+			}
+			if val, i, err = t.value(i); err != nil {
+				return nil, 0, err
+			}
+			m[key] = val
+		}
+		return m, i, nil
+	}
+	return nil, i, nil
+}
+
+// bigInt parses the digits of a GOJS_BIGINT node.
+func (t *tape) bigInt(n *C.gojs_node) (*big.Int, error) {
+	str := t.str(n)
+	x, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("gojs: cannot parse BigInt %q", str)
+	}
+	return x, nil
+}
+
+// dateTime converts a JavaScript time value to a time.Time in UTC. Invalid
+// dates are NaN.
+func dateTime(ms float64) (time.Time, bool) {
+	if math.IsNaN(ms) {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(int64(ms)).In(time.UTC), true
+}
+
+var kindNames = map[C.int]string{
+	C.GOJS_UNDEFINED: "undefined",
+	C.GOJS_NULL:      "null",
+	C.GOJS_BOOLEAN:   "boolean",
+	C.GOJS_NUMBER:    "number",
+	C.GOJS_STRING:    "string",
+	C.GOJS_ARRAY:     "array",
+	C.GOJS_OBJECT:    "object",
+	C.GOJS_DATE:      "Date",
+	C.GOJS_MAP:       "Map",
+	C.GOJS_SET:       "Set",
+	C.GOJS_BIGINT:    "BigInt",
+}
+
+// DecodeError reports a JavaScript value that could not be stored in a Go
+// value by Decode.
+type DecodeError struct {
+	// Path locates the value within the decoded value, as in ".a[2]".
+	Path string
+	// Value names the kind of JavaScript value, such as "number".
+	Value  string
+	GoType reflect.Type
+	// Reason is set when the kinds match but the value does not fit, as
+	// for 1.5 decoded into an int.
+	Reason string
+}
+
+func (e *DecodeError) Error() string {
+	msg := "gojs: cannot decode JavaScript " + e.Value + " into Go value of type " + e.GoType.String()
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
+
+// Decode stores the Go form of v in the value pointed to by dst. It follows
+// the rules of encoding/json, reading the whole of v in a single call into
+// JavaScriptCore:
+//
+//   - Objects fill structs, matching keys to json tags or field names,
+//     including those promoted from embedded structs, and maps with string
+//     or integer keys. Unknown keys are ignored.
+//   - Maps fill Go maps, with their keys decoded like values.
+//   - Sets fill maps with struct{} or bool values, slices and arrays.
+//   - Arrays fill slices and arrays.
+//   - Numbers fill any numeric type they fit in exactly.
+//   - BigInts fill integer types they fit in and big.Int, and are *big.Int
+//     in an interface{}.
+//   - Dates fill time.Time, and are time.Time in an interface{}.
+//   - null and undefined set pointers, slices, maps and interfaces to nil
+//     and leave other values unchanged.
+//
+// Functions and symbols are left out of objects and are null in arrays.
+// Values that contain themselves, and arrays, Maps and Sets of more than
+// 2^24 elements, cannot be decoded.
+func (v *Value) Decode(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("gojs: Decode needs a non-nil pointer, not %T", dst)
+	}
+	t, err := v.ctx.flatten(v)
+	if err != nil {
+		return err
+	}
+	_, err = t.decode(0, rv.Elem(), "")
+	return err
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bigIntType = reflect.TypeOf(big.Int{})
+)
+
+func (t *tape) decode(i int, dst reflect.Value, path string) (int, error) {
+	n := &t.nodes[i]
+	fail := func(reason string) (int, error) {
+		return 0, &DecodeError{path, kindNames[n.kind], dst.Type(), reason}
+	}
+
+	if n.kind == C.GOJS_NULL || n.kind == C.GOJS_UNDEFINED {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return i + 1, nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return fail("")
+		}
+		val, next, err := t.value(i)
+		if err != nil {
+			return 0, err
+		}
+		if val == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(reflect.ValueOf(val))
+		}
+		return next, nil
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return t.decode(i, dst.Elem(), path)
+	}
+
+	if dst.Type() == timeType {
+		date, ok := dateTime(float64(n.number))
+		if n.kind != C.GOJS_DATE || !ok {
+			return fail("")
+		}
+		dst.Set(reflect.ValueOf(date))
+		return i + 1, nil
+	}
+	if dst.Type() == bigIntType {
+		if n.kind != C.GOJS_BIGINT {
+			return fail("")
+		}
+		x, err := t.bigInt(n)
+		if err != nil {
+			return 0, err
+		}
+		dst.Set(reflect.ValueOf(x).Elem())
+		return i + 1, nil
+	}
+
+	switch n.kind {
+	case C.GOJS_BOOLEAN:
+		if dst.Kind() != reflect.Bool {
+			return fail("")
+		}
+		dst.SetBool(n.number != 0)
+	case C.GOJS_STRING:
+		if dst.Kind() != reflect.String {
+			return fail("")
+		}
+		dst.SetString(t.str(n))
+	case C.GOJS_NUMBER:
+		num := float64(n.number)
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if num != math.Trunc(num) || num < math.MinInt64 || num >= math.MaxInt64 || dst.OverflowInt(int64(num)) {
+				return fail("out of range or not an integer")
+			}
+			dst.SetInt(int64(num))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if num != math.Trunc(num) || num < 0 || num >= math.MaxUint64 || dst.OverflowUint(uint64(num)) {
+				return fail("out of range or not an integer")
+			}
+			dst.SetUint(uint64(num))
+		case reflect.Float32, reflect.Float64:
+			if !math.IsInf(num, 0) && !math.IsNaN(num) && dst.OverflowFloat(num) {
+				return fail("out of range")
+			}
+			dst.SetFloat(num)
+		default:
+			return fail("")
+		}
+	case C.GOJS_BIGINT:
+		x, err := t.bigInt(n)
+		if err != nil {
+			return 0, err
+		}
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !x.IsInt64() || dst.OverflowInt(x.Int64()) {
+				return fail("out of range")
+			}
+			dst.SetInt(x.Int64())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if !x.IsUint64() || dst.OverflowUint(x.Uint64()) {
+				return fail("out of range")
+			}
+			dst.SetUint(x.Uint64())
+		default:
+			return fail("")
+		}
+	case C.GOJS_ARRAY:
+		return t.decodeArray(i, dst, path, fail)
+	case C.GOJS_OBJECT:
+		return t.decodeObject(i, dst, path, fail)
+	case C.GOJS_MAP:
+		return t.decodeMap(i, dst, path, fail)
+	case C.GOJS_SET:
+		if dst.Kind() == reflect.Map {
+			return t.decodeSet(i, dst, path, fail)
+		}
+		return t.decodeArray(i, dst, path, fail)
+	default:
+		return fail("")
+	}
+	return i + 1, nil
+}
+
+func (t *tape) decodeArray(i int, dst reflect.Value, path string, fail func(string) (int, error)) (int, error) {
+	length := int(t.nodes[i].len)
+	i++
+	switch dst.Kind() {
+	case reflect.Slice:
+		dst.Set(reflect.MakeSlice(dst.Type(), length, length))
+	case reflect.Array:
+	default:
+		return fail("")
+	}
+
+	var err error
+	for j := 0; j < length; j++ {
+		if j >= dst.Len() {
+			i = t.skip(i)
+			continue
+		}
+		if i, err = t.decode(i, dst.Index(j), path+"["+strconv.Itoa(j)+"]"); err != nil {
+			return 0, err
+		}
+	}
+	for j := length; j < dst.Len(); j++ {
+		dst.Index(j).Set(reflect.Zero(dst.Type().Elem()))
+	}
+	return i, nil
+}
+
+func (t *tape) decodeObject(i int, dst reflect.Value, path string, fail func(string) (int, error)) (int, error) {
+	length := int(t.nodes[i].len)
+	i++
+
+	var err error
+	switch dst.Kind() {
+	case reflect.Map:
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), length))
+		}
+		for j := 0; j < length; j++ {
+			key := t.str(&t.nodes[i])
+			k, ok := parseMapKey(key, dst.Type().Key())
+			if !ok {
+				return 0, &DecodeError{path + "." + key, "object key", dst.Type().Key(), ""}
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if i, err = t.decode(i+1, elem, path+"."+key); err != nil {
+				return 0, err
+			}
+			dst.SetMapIndex(k, elem)
+		}
+	case reflect.Struct:
+		for j := 0; j < length; j++ {
+			key := t.str(&t.nodes[i])
+			field := fieldByKey(dst, key)
+			if !field.IsValid() {
+				i = t.skip(i + 1)
+				continue
+			}
+			if i, err = t.decode(i+1, field, path+"."+key); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return fail("")
+	}
+	return i, nil
+}
+
+// parseMapKey converts an object key to a Go map key, which may be a string
+// or, as in encoding/json, an integer.
+func parseMapKey(key string, typ reflect.Type) (reflect.Value, bool) {
+	k := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(n) {
+			return k, false
+		}
+		k.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(n) {
+			return k, false
+		}
+		k.SetUint(n)
+	default:
+		return k, false
+	}
+	return k, true
+}
+
+// decodeMap decodes a JavaScript Map into a Go map, decoding its keys as
+// values of the map's key type.
+func (t *tape) decodeMap(i int, dst reflect.Value, path string, fail func(string) (int, error)) (int, error) {
+	length := int(t.nodes[i].len)
+	i++
+	if dst.Kind() != reflect.Map {
+		return fail("")
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), length))
+	}
+
+	var err error
+	for j := 0; j < length; j++ {
+		entry := path + "[" + strconv.Itoa(j) + "]"
+		key := reflect.New(dst.Type().Key()).Elem()
+		if i, err = t.decode(i, key, entry+".key"); err != nil {
+			return 0, err
+		}
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		if i, err = t.decode(i, elem, entry+".value"); err != nil {
+			return 0, err
+		}
+		dst.SetMapIndex(key, elem)
+	}
+	return i, nil
+}
+
+// decodeSet decodes a JavaScript Set into the keys of a Go map with struct{}
+// or bool values.
+func (t *tape) decodeSet(i int, dst reflect.Value, path string, fail func(string) (int, error)) (int, error) {
+	length := int(t.nodes[i].len)
+	i++
+	elemType := dst.Type().Elem()
+	var present reflect.Value
+	switch {
+	case elemType.Kind() == reflect.Struct && elemType.NumField() == 0:
+		present = reflect.Zero(elemType)
+	case elemType.Kind() == reflect.Bool:
+		present = reflect.ValueOf(true).Convert(elemType)
+	default:
+		return fail("set elements need a map with struct{} or bool values")
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), length))
+	}
+
+	var err error
+	for j := 0; j < length; j++ {
+		key := reflect.New(dst.Type().Key()).Elem()
+		if i, err = t.decode(i, key, path+"["+strconv.Itoa(j)+"]"); err != nil {
+			return 0, err
+		}
+		dst.SetMapIndex(key, present)
+	}
+	return i, nil
+}
+
+// jsonField is a field of a struct type as encoding/json sees it: name is
+// its json tag or Go name, and index its path through embedded structs.
+type jsonField struct {
+	name   string
+	index  []int
+	tagged bool
+}
+
+// jsonFieldCache caches the []jsonField of each struct type.
+var jsonFieldCache sync.Map
+
+// jsonFields lists the fields encoding/json would decode into for the struct
+// type typ. Fields of embedded structs without a json tag are promoted; of
+// the fields of one name, the shallowest wins, then the only tagged one, and
+// names left ambiguous are dropped.
+func jsonFields(typ reflect.Type) []jsonField {
+	if f, ok := jsonFieldCache.Load(typ); ok {
+		return f.([]jsonField)
+	}
+
+	var fields []jsonField
+	current := []embedded{{typ, nil}}
+	seen := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var level []jsonField
+		var next []embedded
+		// As in walkFields, a type reached twice at one depth makes its
+		// fields ambiguous.
+		count := map[reflect.Type]int{}
+		for _, e := range current {
+			if seen[e.typ] || count[e.typ] == 2 {
+				continue
+			}
+			count[e.typ]++
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				if i := strings.IndexByte(tag, ','); i >= 0 {
+					tag = tag[:i]
+				}
+				index := append(append([]int(nil), e.index...), i)
+				if tag == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+				name := tag
+				if name == "" {
+					name = sf.Name
+				}
+				level = append(level, jsonField{name, index, tag != ""})
+			}
+		}
+
+		for t := range count {
+			seen[t] = true
+		}
+
+		// Keep the fields whose names no shallower field has taken, and of
+		// several at this depth, the only tagged one.
+		taken := make(map[string]bool, len(fields))
+		for _, f := range fields {
+			taken[f.name] = true
+		}
+		byName := make(map[string][]jsonField)
+		var order []string
+		for _, f := range level {
+			if taken[f.name] {
+				continue
+			}
+			if byName[f.name] == nil {
+				order = append(order, f.name)
+			}
+			byName[f.name] = append(byName[f.name], f)
+		}
+		for _, name := range order {
+			candidates := byName[name]
+			var tagged []jsonField
+			for _, f := range candidates {
+				if f.tagged {
+					tagged = append(tagged, f)
+				}
+			}
+			switch {
+			case len(candidates) == 1:
+				fields = append(fields, candidates[0])
+			case len(tagged) == 1:
+				fields = append(fields, tagged[0])
+			default:
+				// Ambiguous: no field takes the name, nor does a deeper one.
+				fields = append(fields, jsonField{name: name})
+			}
+		}
+		current = next
+	}
+
+	kept := fields[:0]
+	for _, f := range fields {
+		if f.index != nil {
+			kept = append(kept, f)
+		}
+	}
+	f, _ := jsonFieldCache.LoadOrStore(typ, kept)
+	return f.([]jsonField)
+}
+
+// fieldByKey finds the field of the struct v for key as encoding/json does,
+// preferring an exact match of its json tag or name to a case-insensitive
+// one. Nil pointers to embedded structs along the way are allocated; it
+// returns an invalid Value if one cannot be.
+func fieldByKey(v reflect.Value, key string) reflect.Value {
+	fields := jsonFields(v.Type())
+	match := -1
+	for i, f := range fields {
+		if f.name == key {
+			match = i
+			break
+		}
+		if match < 0 && equalFold(f.name, key) {
+			match = i
+		}
+	}
+	if match < 0 {
+		return reflect.Value{}
+	}
+
+	for i, n := range fields[match].index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(n)
+	}
+	return v
+}
+
+func equalFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		x, y := a[i], b[i]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// encode appends the flattened form of the Go value v to t.
+func (t *tape) encode(v reflect.Value) error {
+	node := C.gojs_node{kind: C.GOJS_NULL}
+	if v.IsValid() && v.Type() == timeType {
+		node.kind = C.GOJS_DATE
+		node.number = C.double(v.Interface().(time.Time).UnixMilli())
+		t.nodes = append(t.nodes, node)
+		return nil
+	}
+
+	switch k := v.Kind(); k {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		key := visit{v.Pointer(), 0, v.Type()}
+		if k == reflect.Slice {
+			key.len = v.Len()
+		}
+		if t.visiting[key] {
+			return errors.New("gojs: cannot convert a value that contains itself")
+		}
+		if t.visiting == nil {
+			t.visiting = make(map[visit]bool)
+		}
+		t.visiting[key] = true
+		defer delete(t.visiting, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			return t.encode(v.Elem())
+		}
+	case reflect.Bool:
+		node.kind = C.GOJS_BOOLEAN
+		if v.Bool() {
+			node.number = 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		num, str, as, err := intConversion(v, t.opts.Int64)
+		if err != nil {
+			return err
+		}
+		switch as {
+		case Int64AsBigInt:
+			t.encodeChars(C.GOJS_BIGINT, str)
+			t.bigints = true
+			return nil
+		case Int64AsString:
+			t.encodeString(str)
+			return nil
+		}
+		node.kind, node.number = C.GOJS_NUMBER, C.double(num)
+	case reflect.Float32, reflect.Float64:
+		node.kind, node.number = C.GOJS_NUMBER, C.double(v.Float())
+	case reflect.String:
+		t.encodeString(v.String())
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			break
+		}
+		t.nodes = append(t.nodes, C.gojs_node{kind: C.GOJS_ARRAY, len: C.size_t(v.Len())})
+		for i := 0; i < v.Len(); i++ {
+			if err := t.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		// Maps become objects, Maps or Sets as in mapToJSValue.
+		if v.IsNil() {
+			break
+		}
+		keys := sortedKeys(v)
+		elemType := v.Type().Elem()
+		switch {
+		case t.opts.Sets && elemType.Kind() == reflect.Struct && elemType.NumField() == 0:
+			t.sets = true
+			t.nodes = append(t.nodes, C.gojs_node{kind: C.GOJS_SET, len: C.size_t(len(keys))})
+			for _, key := range keys {
+				if err := t.encode(key); err != nil {
+					return err
+				}
+			}
+		case !t.opts.Maps && v.Type().Key().Kind() == reflect.String:
+			t.nodes = append(t.nodes, C.gojs_node{kind: C.GOJS_OBJECT, len: C.size_t(len(keys))})
+			for _, key := range keys {
+				t.encodeString(key.String())
+				if err := t.encode(v.MapIndex(key)); err != nil {
+					return err
+				}
+			}
+		default:
+			t.maps = true
+			t.nodes = append(t.nodes, C.gojs_node{kind: C.GOJS_MAP, len: C.size_t(len(keys))})
+			for _, key := range keys {
+				if err := t.encode(key); err != nil {
+					return err
+				}
+				if err := t.encode(v.MapIndex(key)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("gojs: cannot convert Go value of type %s", v.Type())
+	}
+	t.nodes = append(t.nodes, node)
+	return nil
+}
+
+func (t *tape) encodeString(s string) {
+	t.encodeChars(C.GOJS_STRING, s)
+}
+
+// encodeChars appends a node of kind holding the characters of s.
+func (t *tape) encodeChars(kind C.int, s string) {
+	offset := len(t.chars)
+	t.chars = appendUTF16(t.chars, s)
+	t.nodes = append(t.nodes, C.gojs_node{kind: kind, offset: C.size_t(offset), len: C.size_t(len(t.chars) - offset)})
+}
+
+// NewArrayFromSlice creates an array from a Go slice or array in a single
+// call into JavaScriptCore. Elements may be booleans, numbers, strings,
+// time.Time, nil, and slices and maps of those, which become nested arrays
+// and objects, or Maps and Sets. Maps and integers are converted as NewValue
+// converts them, following the context's ConvertOptions.
+func (ctx *Context) NewArrayFromSlice(slice interface{}) (*Object, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("gojs: NewArrayFromSlice needs a slice or array, not %T", slice)
+	}
+	t := &tape{opts: ctx.convertOptions()}
+	t.nodes = append(t.nodes, C.gojs_node{kind: C.GOJS_ARRAY, len: C.size_t(v.Len())})
+	for i := 0; i < v.Len(); i++ {
+		if err := t.encode(v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+
+	var chars *C.JSChar
+	if len(t.chars) > 0 {
+		chars = (*C.JSChar)(unsafe.Pointer(&t.chars[0]))
+	}
+	var makers C.gojs_makers
+	for _, m := range []struct {
+		used bool
+		ref  *C.JSObjectRef
+		src  string
+	}{
+		{t.bigints, &makers.bigint, newBigIntHelper},
+		{t.maps, &makers._map, newMapHelper},
+		{t.sets, &makers.set, newSetHelper},
+	} {
+		if !m.used {
+			continue
+		}
+		fn, err := ctx.helper(m.src)
+		if err != nil {
+			return nil, err
+		}
+		*m.ref = fn.ref
+	}
+	errVal := ctx.newErrorValue()
+	ret := C.gojs_build(ctx.ref, &t.nodes[0], C.size_t(len(t.nodes)), chars, &makers, &errVal.ref)
+	if errVal.ref != nil {
+		return nil, errVal
+	}
+	if ret == nil {
+		return nil, errors.New("gojs: out of memory")
+	}
+	return ctx.newObject(C.JSObjectRef(unsafe.Pointer(ret))), nil
+}
+
+// ConvertOptions control how values are converted between Go and
+// JavaScript.
+type ConvertOptions struct {
+	// Maps converts Go maps to JavaScript Maps rather than plain objects.
+	// Go maps whose keys are not strings always become Maps.
+	Maps bool
+
+	// Sets converts Go maps with struct{} values, such as
+	// map[string]struct{}, to JavaScript Sets of their keys.
+	Sets bool
+
+	// Int64 selects how int64 and uint64 values are converted.
+	Int64 Int64Conversion
+
+	// LooseNumbers relaxes the conversion of values stored in numeric
+	// fields of native objects and passed as numeric arguments to native
+	// functions: any value is converted as the unary + operator would, and
+	// fractions are truncated. Overflow, NaN and infinities are rejected
+	// either way.
+	LooseNumbers bool
+}
+
+// SetConvertOptions sets the options used by NewValue and by native
+// functions and objects. Like the CrossContextPolicy, they are shared by
+// every *Context wrapping the same global context.
+func (ctx *Context) SetConvertOptions(opts ConvertOptions) {
+	s := ctx.state()
+	s.mu.Lock()
+	s.convert = opts
+	s.mu.Unlock()
+}
+
+func (ctx *Context) convertOptions() ConvertOptions {
+	s := ctx.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.convert
+}
+
+// mapToJSValue converts a Go map to a plain object, a Map or a Set according
+// to the context's ConvertOptions. Keys are added in sorted order where
+// they can be ordered.
+func (ctx *Context) mapToJSValue(m reflect.Value) (*Value, error) {
+	if m.IsNil() {
+		return ctx.NewNullValue(), nil
+	}
+	opts := ctx.convertOptions()
+	keys := sortedKeys(m)
+
+	elemType := m.Type().Elem()
+	if opts.Sets && elemType.Kind() == reflect.Struct && elemType.NumField() == 0 {
+		values := make([]*Value, len(keys))
+		for i, key := range keys {
+			v, err := ctx.toJSValue(key)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		arr, err := ctx.NewArray(values)
+		if err != nil {
+			return nil, err
+		}
+		return ctx.callHelper(newSetHelper, arr.ToValue())
+	}
+
+	if !opts.Maps && m.Type().Key().Kind() == reflect.String {
+		obj := ctx.NewEmptyObject()
+		for _, key := range keys {
+			v, err := ctx.toJSValue(m.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			if err := obj.Set(key.String(), v); err != nil {
+				return nil, err
+			}
+		}
+		return obj.ToValue(), nil
+	}
+
+	entries := make([]*Value, len(keys))
+	for i, key := range keys {
+		k, err := ctx.toJSValue(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ctx.toJSValue(m.MapIndex(key))
+		if err != nil {
+			return nil, err
+		}
+		entry, err := ctx.NewArray([]*Value{k, v})
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry.ToValue()
+	}
+	arr, err := ctx.NewArray(entries)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.callHelper(newMapHelper, arr.ToValue())
+}
+
+// newMapHelper makes a Map from an array of entries, and newSetHelper a Set
+// from an array of values.
+const (
+	newMapHelper = "(function (Map) { return function (entries) { return new Map(entries); }; })(Map)"
+	newSetHelper = "(function (Set) { return function (values) { return new Set(values); }; })(Set)"
+)
+
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	var less func(a, b reflect.Value) bool
+	switch m.Type().Key().Kind() {
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	default:
+		return keys
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
@@ -0,0 +1,545 @@
+package gojs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	type point struct {
+		X, Y int
+		Tag  string `json:"label"`
+	}
+	var dst struct {
+		Name   string
+		Count  uint8
+		Ratio  float32
+		Points []point
+		Seen   map[string]bool
+		When   time.Time
+		Any    interface{}
+		Next   *point
+		Fixed  [2]int
+		hidden int
+	}
+	v, err := ctx.EvaluateScript(`({
+		name: "été 😀", count: 200, ratio: 0.5,
+		points: [{x: 1, y: 2, label: "a"}, {X: 3, unknown: {deep: [1]}}],
+		seen: {a: true, b: false},
+		when: new Date(1500),
+		any: [1, "two", null],
+		next: null,
+		fixed: [7],
+		hidden: 5
+	})`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if err := v.Decode(&dst); err != nil {
+		t.Fatalf("v.Decode failed: %v", err)
+	}
+
+	if dst.Name != "été 😀" || dst.Count != 200 || dst.Ratio != 0.5 {
+		t.Errorf("v.Decode primitives = %q %d %v", dst.Name, dst.Count, dst.Ratio)
+	}
+	if want := []point{{1, 2, "a"}, {3, 0, ""}}; !reflect.DeepEqual(dst.Points, want) {
+		t.Errorf("v.Decode points = %+v, want %+v", dst.Points, want)
+	}
+	if want := map[string]bool{"a": true, "b": false}; !reflect.DeepEqual(dst.Seen, want) {
+		t.Errorf("v.Decode map = %v, want %v", dst.Seen, want)
+	}
+	if want := time.Unix(1, 500000000).In(time.UTC); !dst.When.Equal(want) {
+		t.Errorf("v.Decode date = %v, want %v", dst.When, want)
+	}
+	if want := []interface{}{1.0, "two", nil}; !reflect.DeepEqual(dst.Any, want) {
+		t.Errorf("v.Decode interface = %#v, want %#v", dst.Any, want)
+	}
+	if dst.Next != nil || dst.Fixed != [2]int{7, 0} || dst.hidden != 0 {
+		t.Errorf("v.Decode = %+v %v %d", dst.Next, dst.Fixed, dst.hidden)
+	}
+}
+
+type decode_base struct {
+	ID   int
+	Name string
+}
+
+type decode_meta struct {
+	Name    string `json:"name"`
+	Created int
+}
+
+type decode_extra struct{ Created int }
+
+func TestDecodeEmbedded(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	// As with encoding/json, fields of embedded structs are promoted, the
+	// tagged Name beats the untagged one at the same depth, and the
+	// ambiguous Created is ignored.
+	var dst struct {
+		decode_base
+		*decode_meta
+		decode_extra
+		Title string
+	}
+	// The embedded pointer is to an unexported type, so it cannot be
+	// allocated by Decode.
+	dst.decode_meta = new(decode_meta)
+	v, err := ctx.EvaluateScript(`({id: 7, name: "n", created: 3, title: "t"})`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if err := v.Decode(&dst); err != nil {
+		t.Fatalf("v.Decode failed: %v", err)
+	}
+	if dst.ID != 7 || dst.Title != "t" || dst.decode_base.Name != "" {
+		t.Errorf("v.Decode = %+v", dst)
+	}
+	if dst.decode_meta.Name != "n" || dst.decode_meta.Created != 0 || dst.decode_extra.Created != 0 {
+		t.Errorf("v.Decode embedded pointer = %+v", dst.decode_meta)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	tests := []struct {
+		script string
+		dst    interface{}
+		path   string
+	}{
+		{`({a: [1, 2, "x"]})`, new(struct{ A []int }), ".a[2]"},
+		{`({n: 1.5})`, new(struct{ N int }), ".n"},
+		{`({n: 300})`, new(struct{ N int8 }), ".n"},
+		{`({n: -1})`, new(struct{ N uint }), ".n"},
+		{`[true]`, new([]string), "[0]"},
+	}
+	for _, test := range tests {
+		v, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Fatalf("ctx.EvaluateScript failed: %v", err)
+		}
+		err = v.Decode(test.dst)
+		derr, ok := err.(*DecodeError)
+		if !ok {
+			t.Errorf("v.Decode(%s) = %v, want *DecodeError", test.script, err)
+			continue
+		}
+		if derr.Path != test.path {
+			t.Errorf("v.Decode(%s) path = %q, want %q", test.script, derr.Path, test.path)
+		}
+	}
+
+	v := ctx.NewNumberValue(1)
+	var n int
+	if err := v.Decode(n); err == nil {
+		t.Errorf("v.Decode with a non-pointer succeeded")
+	}
+}
+
+func TestGoValueCyclic(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript("var o = {}; o.self = o; o", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if _, err := v.GoValue(); err == nil || !strings.Contains(err.Error(), "contains itself") {
+		t.Errorf("v.GoValue on a cyclic object = %v", err)
+	}
+
+	// Shared, acyclic references are fine.
+	v, err = ctx.EvaluateScript("var x = [1]; [x, x, {f: function () {}, u: undefined}]", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	got, err := v.GoValue()
+	if err != nil {
+		t.Fatalf("v.GoValue failed: %v", err)
+	}
+	want := []interface{}{[]interface{}{1.0}, []interface{}{1.0}, map[string]interface{}{}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("v.GoValue = %#v, want %#v", got, want)
+	}
+}
+
+func TestGoValueArrayLength(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	// A sparse array claiming 2^32-1 elements is refused without walking it.
+	v, err := ctx.EvaluateScript("var a = []; a.length = 4294967295; a", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if _, err := v.GoValue(); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Errorf("v.GoValue on a huge array = %v", err)
+	}
+
+	// Inheriting from Array.prototype does not make an object an array.
+	v, err = ctx.EvaluateScript("Object.create(Array.prototype, {length: {value: 4294967295}})", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	got, err := v.GoValue()
+	if err != nil || !reflect.DeepEqual(got, map[string]interface{}{}) {
+		t.Errorf("v.GoValue on an array-like object = %#v, %v", got, err)
+	}
+}
+
+func TestGoValueGettersRunOnce(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	// A value larger than the initial buffers, with a getter that makes it
+	// grow each time it is read.
+	v, err := ctx.EvaluateScript(`var calls = 0;
+		var o = { items: [] };
+		Object.defineProperty(o, 'more', { enumerable: true, get: function () {
+			calls++;
+			for (var i = 0; i < 100; i++) o.items.push('abcdefghijklmnopqrstuvwxyz');
+			return calls;
+		} });
+		for (var i = 0; i < 100; i++) o.items.push('abcdefghijklmnopqrstuvwxyz');
+		o`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if _, err := v.GoValue(); err != nil {
+		t.Fatalf("v.GoValue failed: %v", err)
+	}
+	calls, err := ctx.EvaluateScript("calls", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if calls.String() != "1" {
+		t.Errorf("the getter ran %s times, want 1", calls)
+	}
+}
+
+func TestNewArrayFromSlice(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	in := []interface{}{
+		1, "two", true, nil,
+		[]float64{0.5},
+		map[string]interface{}{"k": "v☃"},
+		time.Unix(2, 0),
+	}
+	arr, err := ctx.NewArrayFromSlice(in)
+	if err != nil {
+		t.Fatalf("ctx.NewArrayFromSlice failed: %v", err)
+	}
+	got, err := arr.ToValue().GoValue()
+	if err != nil {
+		t.Fatalf("GoValue failed: %v", err)
+	}
+	want := []interface{}{
+		1.0, "two", true, nil,
+		[]interface{}{0.5},
+		map[string]interface{}{"k": "v☃"},
+		time.Unix(2, 0).In(time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ctx.NewArrayFromSlice round trip = %#v, want %#v", got, want)
+	}
+
+	if _, err := ctx.NewArrayFromSlice([]chan int{nil}); err == nil {
+		t.Errorf("ctx.NewArrayFromSlice with channels succeeded")
+	}
+	if _, err := ctx.NewArrayFromSlice(5); err == nil {
+		t.Errorf("ctx.NewArrayFromSlice with a number succeeded")
+	}
+
+	// Values that contain themselves fail rather than recursing forever,
+	// while shared values that do not are converted.
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	loop := []interface{}{nil}
+	loop[0] = loop
+	for _, v := range []interface{}{[]interface{}{cyclic}, loop} {
+		if _, err := ctx.NewArrayFromSlice(v); err == nil || !strings.Contains(err.Error(), "contains itself") {
+			t.Errorf("ctx.NewArrayFromSlice with a cyclic value returned %v", err)
+		}
+	}
+	shared := []float64{1}
+	if _, err := ctx.NewArrayFromSlice([]interface{}{shared, shared}); err != nil {
+		t.Errorf("ctx.NewArrayFromSlice with a shared slice failed: %v", err)
+	}
+}
+
+func TestNewArrayFromSliceMapSet(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	in := []interface{}{
+		map[int]string{2: "two", 1: "one"},
+		map[string]int{"b": 2, "a": 1},
+		map[string]struct{}{"y": {}, "x": {}},
+	}
+	check := func(want string) {
+		t.Helper()
+		arr, err := ctx.NewArrayFromSlice(in)
+		if err != nil {
+			t.Fatalf("ctx.NewArrayFromSlice failed: %v", err)
+		}
+		fn, err := ctx.EvaluateScript(`(function (a) {
+			return a.map(function (m) {
+				if (m instanceof Map || m instanceof Set) return m.constructor.name + JSON.stringify(Array.from(m));
+				return JSON.stringify(m);
+			}).join(" ");
+		})`, nil, "", 1)
+		if err != nil {
+			t.Fatalf("ctx.EvaluateScript failed: %v", err)
+		}
+		ret, err := fn.ToObjectOrDie().Call(nil, arr.ToValue())
+		if err != nil {
+			t.Fatalf("calling the check failed: %v", err)
+		}
+		if got := ret.String(); got != want {
+			t.Errorf("ctx.NewArrayFromSlice = %s, want %s", got, want)
+		}
+	}
+
+	check(`Map[[1,"one"],[2,"two"]] {"a":1,"b":2} {"x":{},"y":{}}`)
+	ctx.SetConvertOptions(ConvertOptions{Maps: true, Sets: true})
+	check(`Map[[1,"one"],[2,"two"]] Map[["a",1],["b",2]] Set["x","y"]`)
+}
+
+const benchmarkLength = 100000
+
+func benchmarkArray(b *testing.B, ctx *Context) *Value {
+	v, err := ctx.EvaluateScript("var a = []; for (var i = 0; i < 100000; i++) a.push(i % 2 ? i : 'item' + i); a", nil, "", 1)
+	if err != nil {
+		b.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	return v
+}
+
+func BenchmarkGoValueArray(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	v := benchmarkArray(b, ctx)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := v.GoValue(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGoValueArrayJSON measures the JSON round trip GoValue used to make.
+func BenchmarkGoValueArrayJSON(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	v := benchmarkArray(b, ctx)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := v.JSON()
+		if err != nil {
+			b.Fatal(err)
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGoValueArrayByIndex measures converting one element at a time.
+func BenchmarkGoValueArrayByIndex(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	obj := benchmarkArray(b, ctx).ToObjectOrDie()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := make([]interface{}, benchmarkLength)
+		for j := range out {
+			item, err := obj.GetIndex(uint32(j))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if item.IsNumber() {
+				out[j] = item.ToNumberOrDie()
+			} else {
+				out[j] = item.ToStringOrDie()
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeArray(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	v, err := ctx.EvaluateScript("var a = []; for (var i = 0; i < 100000; i++) a.push(i); a", nil, "", 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out []int
+		if err := v.Decode(&out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewArrayFromSlice(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	in := make([]float64, benchmarkLength)
+	for i := range in {
+		in[i] = float64(i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ctx.NewArrayFromSlice(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkNewArrayByValue measures creating every element with its own call.
+func BenchmarkNewArrayByValue(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	in := make([]float64, benchmarkLength)
+	for i := range in {
+		in[i] = float64(i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		values := make([]*Value, len(in))
+		for j, f := range in {
+			values[j] = ctx.NewNumberValue(f)
+		}
+		if _, err := ctx.NewArray(values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNewValueMap(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	check := func(v *Value, script, want string) {
+		t.Helper()
+		fn, err := ctx.EvaluateScript("(function (m) { return "+script+"; })", nil, "", 1)
+		if err != nil {
+			t.Fatalf("ctx.EvaluateScript failed: %v", err)
+		}
+		ret, err := fn.ToObjectOrDie().Call(nil, v)
+		if err != nil {
+			t.Fatalf("%s failed: %v", script, err)
+		}
+		if got := ret.String(); got != want {
+			t.Errorf("%s = %q, want %q", script, got, want)
+		}
+	}
+
+	strs := map[string]int{"b": 2, "a": 1}
+	check(ctx.NewValue(strs), "JSON.stringify(m)", `{"a":1,"b":2}`)
+	check(ctx.NewValue(map[int]string{2: "two", 1: "one"}), "m instanceof Map && JSON.stringify(Array.from(m))", `[[1,"one"],[2,"two"]]`)
+	check(ctx.NewValue(map[string]int(nil)), "m", "null")
+
+	ctx.SetConvertOptions(ConvertOptions{Maps: true, Sets: true})
+	check(ctx.NewValue(strs), "m instanceof Map && m.get('b')", "2")
+	check(ctx.NewValue(map[string]struct{}{"y": {}, "x": {}}), "m instanceof Set && Array.from(m).join()", "x,y")
+}
+
+func TestDecodeMapSet(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript(`({
+		byID: new Map([[1, "one"], [2, "two"]]),
+		tags: new Set(["a", "b"]),
+		flags: new Set([3]),
+		list: new Set([1, 2]),
+		numbered: {"10": true}
+	})`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	var dst struct {
+		ByID     map[int]string
+		Tags     map[string]struct{}
+		Flags    map[uint8]bool
+		List     []int
+		Numbered map[int]bool
+	}
+	if err := v.Decode(&dst); err != nil {
+		t.Fatalf("v.Decode failed: %v", err)
+	}
+	if want := map[int]string{1: "one", 2: "two"}; !reflect.DeepEqual(dst.ByID, want) {
+		t.Errorf("v.Decode Map = %v, want %v", dst.ByID, want)
+	}
+	if want := map[string]struct{}{"a": {}, "b": {}}; !reflect.DeepEqual(dst.Tags, want) {
+		t.Errorf("v.Decode Set = %v, want %v", dst.Tags, want)
+	}
+	if want := map[uint8]bool{3: true}; !reflect.DeepEqual(dst.Flags, want) {
+		t.Errorf("v.Decode Set into bools = %v, want %v", dst.Flags, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(dst.List, want) {
+		t.Errorf("v.Decode Set into slice = %v, want %v", dst.List, want)
+	}
+	if want := map[int]bool{10: true}; !reflect.DeepEqual(dst.Numbered, want) {
+		t.Errorf("v.Decode object into int keys = %v, want %v", dst.Numbered, want)
+	}
+
+	v, err = ctx.EvaluateScript(`new Map([["k", 1]])`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	var ints map[int]int
+	if err := v.Decode(&ints); err == nil {
+		t.Errorf("v.Decode of string Map keys into int keys succeeded")
+	}
+}
+
+func TestGoValueMapSet(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript(`[new Map([[1, "one"], ["k", [true]]]), new Set(["a", 2])]`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	got, err := v.GoValue()
+	if err != nil {
+		t.Fatalf("v.GoValue failed: %v", err)
+	}
+	want := []interface{}{
+		map[interface{}]interface{}{1.0: "one", "k": []interface{}{true}},
+		[]interface{}{"a", 2.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("v.GoValue = %#v, want %#v", got, want)
+	}
+
+	v, err = ctx.EvaluateScript(`new Map([[{}, 1]])`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	if _, err := v.GoValue(); err == nil {
+		t.Errorf("v.GoValue of a Map with object keys succeeded")
+	}
+}
@@ -0,0 +1,141 @@
+package gojs
+
+// #include <JavaScriptCore/JSContextRef.h>
+import "C"
+
+// CrossContextPolicy selects what a context does with values that belong to
+// a different context group. JavaScriptCore only allows values to be shared
+// between contexts of the same group; handing it a value from another group
+// corrupts the virtual machine.
+type CrossContextPolicy int
+
+const (
+	// CrossContextReject refuses values from another context group with a
+	// *ContextMismatchError. It is the default.
+	CrossContextReject CrossContextPolicy = iota
+
+	// CrossContextClonePrimitives copies undefined, null, booleans, numbers
+	// and strings into the receiving context. Objects are still rejected.
+	CrossContextClonePrimitives
+)
+
+// SetCrossContextPolicy sets the policy applied to values from other context
+// groups passed to this context. Like the tracer, it is shared by every
+// *Context wrapping the same global context.
+func (ctx *Context) SetCrossContextPolicy(policy CrossContextPolicy) {
+	s := ctx.state()
+	s.mu.Lock()
+	s.crossContext = policy
+	s.mu.Unlock()
+}
+
+func (ctx *Context) crossContextPolicy() CrossContextPolicy {
+	s := ctx.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.crossContext
+}
+
+// ContextMismatchError is returned when a value is passed to a context that
+// is not in the context group it was created in.
+type ContextMismatchError struct {
+	// Op is the operation that was given the value, such as "Object.Set".
+	Op string
+}
+
+func (e *ContextMismatchError) Error() string {
+	return "gojs: " + e.Op + ": value belongs to a different context group"
+}
+
+// ReleasedContextError is the panic value raised by builds with the
+// gojsdebug tag when a context is used after its last Release.
+type ReleasedContextError struct {
+	Op string
+}
+
+func (e *ReleasedContextError) Error() string {
+	return "gojs: " + e.Op + ": context used after Release"
+}
+
+// NewContextInGroup creates a new global context in the same context group
+// as other. Values can be passed freely between contexts of one group.
+func NewContextInGroup(other *Context) *Context {
+	ctx := new(Context)
+	ctx.ref = C.JSContextRef(C.JSGlobalContextCreateInGroup(C.JSContextGetGroup(other.ref), nil))
+	ctx.created()
+	return ctx
+}
+
+// shares reports whether values of other can be used in ctx.
+func (ctx *Context) shares(other *Context) bool {
+	if other == nil || ctx.ref == other.ref {
+		return true
+	}
+	if C.JSContextGetGlobalContext(ctx.ref) == C.JSContextGetGlobalContext(other.ref) {
+		return true
+	}
+	return C.JSContextGetGroup(ctx.ref) == C.JSContextGetGroup(other.ref)
+}
+
+// adopt returns v in a form that can be passed to ctx, applying the
+// context's CrossContextPolicy when v comes from another context group.
+func (ctx *Context) adopt(op string, v *Value) (*Value, error) {
+	ctx.checkLive(op)
+	if v == nil {
+		return nil, nil
+	}
+	v.ctx.checkLive(op)
+	if ctx.shares(v.ctx) {
+		return v, nil
+	}
+
+	if ctx.crossContextPolicy() == CrossContextClonePrimitives {
+		switch v.Type() {
+		case TypeUndefined:
+			return ctx.NewUndefinedValue(), nil
+		case TypeNull:
+			return ctx.NewNullValue(), nil
+		case TypeBoolean:
+			return ctx.NewBooleanValue(v.ToBoolean()), nil
+		case TypeNumber:
+			return ctx.NewNumberValue(v.ToNumberOrDie()), nil
+		case TypeString:
+			return ctx.NewStringValue(v.ToStringOrDie()), nil
+		}
+	}
+	ctx.trace(TraceConversion, "rejected value from another context group", "op", op)
+	return nil, &ContextMismatchError{op}
+}
+
+// adoptAll applies adopt to each of values, returning a new slice only if
+// one of them had to be cloned.
+func (ctx *Context) adoptAll(op string, values []*Value) ([]*Value, error) {
+	out := values
+	copied := false
+	for i, v := range values {
+		w, err := ctx.adopt(op, v)
+		if err != nil {
+			return nil, err
+		}
+		if w != v && !copied {
+			out = append([]*Value(nil), values...)
+			copied = true
+		}
+		out[i] = w
+	}
+	return out, nil
+}
+
+// adoptObject checks that obj can be used in ctx. Objects are never cloned.
+func (ctx *Context) adoptObject(op string, obj *Object) error {
+	ctx.checkLive(op)
+	if obj == nil {
+		return nil
+	}
+	obj.ctx.checkLive(op)
+	if ctx.shares(obj.ctx) {
+		return nil
+	}
+	ctx.trace(TraceConversion, "rejected object from another context group", "op", op)
+	return &ContextMismatchError{op}
+}
@@ -0,0 +1,102 @@
+package gojs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCrossContextReject(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	other := NewContext()
+	defer other.Release()
+
+	obj := ctx.NewEmptyObject()
+	err := obj.Set("x", other.NewNumberValue(1))
+	var mismatch *ContextMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("obj.Set with a value from another context returned %v", err)
+	}
+	if mismatch.Op != "Object.Set" {
+		t.Errorf("ContextMismatchError.Op is %q", mismatch.Op)
+	}
+	if obj.Has("x") {
+		t.Errorf("obj.Set stored a value from another context")
+	}
+
+	fn, err := ctx.EvaluateScript("(function (a) { return a; })", nil, "./crosscontext_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if _, err := fn.ToObjectOrDie().Call(nil, other.NewStringValue("a")); !errors.As(err, &mismatch) {
+		t.Errorf("fn.Call with an argument from another context returned %v", err)
+	}
+	if _, err := fn.ToObjectOrDie().Call(other.NewEmptyObject()); !errors.As(err, &mismatch) {
+		t.Errorf("fn.Call with this from another context returned %v", err)
+	}
+	if _, err := ctx.NewArray([]*Value{other.NewNullValue()}); !errors.As(err, &mismatch) {
+		t.Errorf("ctx.NewArray with an item from another context returned %v", err)
+	}
+	if ctx.NewNumberValue(1).StrictEquals(other.NewNumberValue(1)) {
+		t.Errorf("StrictEquals compared values from different contexts")
+	}
+}
+
+func TestCrossContextClonePrimitives(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	other := NewContext()
+	defer other.Release()
+
+	ctx.SetCrossContextPolicy(CrossContextClonePrimitives)
+	obj := ctx.NewEmptyObject()
+	if err := obj.Set("s", other.NewStringValue("copied")); err != nil {
+		t.Fatalf("obj.Set with a string from another context returned %v", err)
+	}
+	if v, _ := obj.Get("s"); v.ToStringOrDie() != "copied" {
+		t.Errorf("cloned string is %v", v)
+	}
+	if !ctx.NewNumberValue(2).StrictEquals(other.NewNumberValue(2)) {
+		t.Errorf("StrictEquals did not clone a number from another context")
+	}
+
+	var mismatch *ContextMismatchError
+	if err := obj.Set("o", other.NewEmptyObject().ToValue()); !errors.As(err, &mismatch) {
+		t.Errorf("obj.Set with an object from another context returned %v", err)
+	}
+}
+
+func TestContextGroup(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	sibling := NewContextInGroup(ctx)
+	defer sibling.Release()
+
+	obj := sibling.NewEmptyObject()
+	if err := obj.Set("n", ctx.NewNumberValue(3)); err != nil {
+		t.Errorf("obj.Set with a value from the same group returned %v", err)
+	}
+	if err := ctx.GlobalObject().Set("shared", obj.ToValue()); err != nil {
+		t.Errorf("obj.Set with an object from the same group returned %v", err)
+	}
+	ret, err := ctx.EvaluateScript("shared.n", nil, "./crosscontext_test.go", 1)
+	if err != nil || ret.ToNumberOrDie() != 3 {
+		t.Errorf("shared object read back as %v, %v", ret, err)
+	}
+}
+
+func TestCrossContextCallbackResult(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	other := NewContext()
+	defer other.Release()
+
+	fn := ctx.NewFunctionWithCallback(func(ctx *Context, obj *Object, thisObject *Object, args []*Value) *Value {
+		return other.NewEmptyObject().ToValue()
+	})
+	ctx.GlobalObject().Set("leak", fn.ToValue())
+	_, err := ctx.EvaluateScript("leak()", nil, "./crosscontext_test.go", 1)
+	if err == nil {
+		t.Errorf("returning an object from another context did not throw")
+	}
+}
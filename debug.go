@@ -0,0 +1,55 @@
+//go:build gojsdebug
+
+package gojs
+
+// #include <JavaScriptCore/JSContextRef.h>
+import "C"
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Builds with the gojsdebug tag remember which contexts have been released
+// and panic with a *ReleasedContextError when one of them, or a value
+// belonging to one, is used again.
+
+var (
+	releasedMu sync.Mutex
+	released   = make(map[C.JSContextRef]bool)
+)
+
+func debugCreated(ref C.JSContextRef) {
+	releasedMu.Lock()
+	delete(released, ref)
+	releasedMu.Unlock()
+}
+
+func debugReleased(ref C.JSContextRef) {
+	releasedMu.Lock()
+	released[ref] = true
+	releasedMu.Unlock()
+}
+
+// checkLive panics if ctx has been released. An empty op is replaced by the
+// name of the calling gojs function.
+func (ctx *Context) checkLive(op string) {
+	if ctx == nil {
+		return
+	}
+	releasedMu.Lock()
+	dead := released[ctx.ref]
+	releasedMu.Unlock()
+	if !dead {
+		return
+	}
+	if op == "" {
+		op = "gojs"
+		if pc, _, _, ok := runtime.Caller(2); ok {
+			if fn := runtime.FuncForPC(pc); fn != nil {
+				op = fn.Name()[strings.LastIndexByte(fn.Name(), '/')+1:]
+			}
+		}
+	}
+	panic(&ReleasedContextError{op})
+}
@@ -0,0 +1,25 @@
+//go:build gojsdebug
+
+package gojs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUseAfterRelease(t *testing.T) {
+	other := NewContext()
+	defer other.Release()
+	ctx := NewContext()
+	v := ctx.NewNumberValue(1)
+	ctx.Release()
+
+	defer func() {
+		r := recover()
+		var released *ReleasedContextError
+		if err, ok := r.(error); !ok || !errors.As(err, &released) {
+			t.Errorf("using a released context panicked with %v", r)
+		}
+	}()
+	other.GlobalObject().Set("v", v)
+}
@@ -0,0 +1,179 @@
+package gojs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Descriptor describes a property in the same terms as a JavaScript property
+// descriptor. A descriptor with a Get or Set function defines an accessor
+// property and must leave Value and Writable unset; any other descriptor
+// defines a data property.
+//
+// As in Object.defineProperty, the boolean attributes default to false.
+type Descriptor struct {
+	// Value is the value of a data property. Nil means undefined.
+	Value *Value
+
+	// Get and Set are the functions of an accessor property. Each may be a
+	// JavaScript function *Object, a GoFunctionCallback, or any Go function
+	// accepted by NewFunctionWithNative. The getter is called with no
+	// arguments and the setter with the assigned value. GetOwnPropertyDescriptor
+	// always reports them as *Object.
+	Get interface{}
+	Set interface{}
+
+	Writable     bool
+	Enumerable   bool
+	Configurable bool
+}
+
+// IsAccessor reports whether d describes an accessor property.
+func (d *Descriptor) IsAccessor() bool {
+	return d.Get != nil || d.Set != nil
+}
+
+// DefineProperty defines or redefines the own property name of obj, as
+// Object.defineProperty does. Redefining a non-configurable property throws
+// a TypeError, which is returned as the error.
+func (obj *Object) DefineProperty(name string, d Descriptor) error {
+	ctx := obj.ctx
+	props := make(map[string]*Value)
+	if d.IsAccessor() {
+		if d.Value != nil || d.Writable {
+			return fmt.Errorf("gojs: property %q: accessor descriptor with Value or Writable", name)
+		}
+		for key, fn := range map[string]interface{}{"get": d.Get, "set": d.Set} {
+			if fn == nil {
+				continue
+			}
+			v, err := ctx.accessorFunction(fn)
+			if err != nil {
+				return fmt.Errorf("gojs: property %q: %v", name, err)
+			}
+			props[key] = v
+		}
+	} else {
+		value, err := ctx.adopt("Object.DefineProperty", d.Value)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			value = ctx.NewUndefinedValue()
+		}
+		props["value"] = value
+		props["writable"] = ctx.NewBooleanValue(d.Writable)
+	}
+	props["enumerable"] = ctx.NewBooleanValue(d.Enumerable)
+	props["configurable"] = ctx.NewBooleanValue(d.Configurable)
+
+	desc, err := ctx.NewObjectWithProperties(props)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.callHelper("Object.defineProperty", obj.ToValue(), ctx.NewStringValue(name), desc.ToValue())
+	return err
+}
+
+// accessorFunction converts the Get or Set field of a Descriptor to a
+// function value.
+func (ctx *Context) accessorFunction(fn interface{}) (*Value, error) {
+	switch fn := fn.(type) {
+	case *Object:
+		if err := ctx.adoptObject("Object.DefineProperty", fn); err != nil {
+			return nil, err
+		}
+		if !fn.IsFunction() {
+			return nil, fmt.Errorf("accessor is not a function")
+		}
+		return fn.ToValue(), nil
+	case GoFunctionCallback:
+		return ctx.NewFunctionWithCallback(fn).ToValue(), nil
+	case func(*Context, *Object, *Object, []*Value) *Value:
+		return ctx.NewFunctionWithCallback(fn).ToValue(), nil
+	}
+	if reflect.TypeOf(fn).Kind() != reflect.Func {
+		return nil, fmt.Errorf("accessor of type %T is not a function", fn)
+	}
+	return ctx.NewFunctionWithNative(fn).ToValue(), nil
+}
+
+// GetOwnPropertyDescriptor describes the own property name of obj. It
+// returns nil if obj has no such own property.
+func (obj *Object) GetOwnPropertyDescriptor(name string) (*Descriptor, error) {
+	ctx := obj.ctx
+	ret, err := ctx.callHelper("Object.getOwnPropertyDescriptor", obj.ToValue(), ctx.NewStringValue(name))
+	if err != nil {
+		return nil, err
+	}
+	if ret.IsUndefined() {
+		return nil, nil
+	}
+	desc, err := ret.ToObject()
+	if err != nil {
+		return nil, err
+	}
+
+	flag := func(key string) bool {
+		v, err := desc.Get(key)
+		return err == nil && v.ToBoolean()
+	}
+	fn := func(key string) interface{} {
+		v, err := desc.Get(key)
+		if err != nil || !v.IsFunction() {
+			return nil
+		}
+		return v.ToObjectOrDie()
+	}
+
+	d := &Descriptor{
+		Enumerable:   flag("enumerable"),
+		Configurable: flag("configurable"),
+	}
+	if desc.Has("get") || desc.Has("set") {
+		d.Get, d.Set = fn("get"), fn("set")
+		return d, nil
+	}
+	if d.Value, err = desc.Get("value"); err != nil {
+		return nil, err
+	}
+	d.Writable = flag("writable")
+	return d, nil
+}
+
+// Freeze makes obj immutable, as Object.freeze does.
+func (obj *Object) Freeze() error {
+	_, err := obj.ctx.callHelper("Object.freeze", obj.ToValue())
+	return err
+}
+
+// Seal prevents properties from being added to or removed from obj, as
+// Object.seal does.
+func (obj *Object) Seal() error {
+	_, err := obj.ctx.callHelper("Object.seal", obj.ToValue())
+	return err
+}
+
+// PreventExtensions prevents properties from being added to obj, as
+// Object.preventExtensions does.
+func (obj *Object) PreventExtensions() error {
+	_, err := obj.ctx.callHelper("Object.preventExtensions", obj.ToValue())
+	return err
+}
+
+func (obj *Object) IsFrozen() bool {
+	return obj.query("Object.isFrozen")
+}
+
+func (obj *Object) IsSealed() bool {
+	return obj.query("Object.isSealed")
+}
+
+func (obj *Object) IsExtensible() bool {
+	return obj.query("Object.isExtensible")
+}
+
+func (obj *Object) query(helper string) bool {
+	ret, err := obj.ctx.callHelper(helper, obj.ToValue())
+	return err == nil && ret.ToBoolean()
+}
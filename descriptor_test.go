@@ -0,0 +1,131 @@
+package gojs
+
+import (
+	"testing"
+)
+
+func TestDefineProperty(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := ctx.NewEmptyObject()
+	err := obj.DefineProperty("fixed", Descriptor{Value: ctx.NewNumberValue(1), Enumerable: true})
+	if err != nil {
+		t.Fatalf("obj.DefineProperty returned an error (%v)", err)
+	}
+
+	calls := 0
+	err = obj.DefineProperty("lazy", Descriptor{
+		Get: func(ctx *Context, _, this *Object, _ []*Value) *Value {
+			calls++
+			fixed, _ := this.Get("fixed")
+			return ctx.NewNumberValue(fixed.ToNumberOrDie() * 10)
+		},
+		Enumerable: true,
+	})
+	if err != nil {
+		t.Fatalf("obj.DefineProperty with a getter returned an error (%v)", err)
+	}
+
+	var stored float64
+	err = obj.DefineProperty("sink", Descriptor{
+		Set: func(v float64) { stored = v },
+	})
+	if err != nil {
+		t.Fatalf("obj.DefineProperty with a setter returned an error (%v)", err)
+	}
+
+	ctx.GlobalObject().Set("o", obj.ToValue())
+	ret, err := ctx.EvaluateScript("'use strict'; o.sink = 7; var threw = false; try { o.fixed = 2 } catch (e) { threw = true }; [o.fixed, o.lazy, threw, Object.keys(o).join()].join()", nil, "./descriptor_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if got := ret.ToStringOrDie(); got != "1,10,true,fixed,lazy" {
+		t.Errorf("script saw %q", got)
+	}
+	if calls != 1 {
+		t.Errorf("getter was called %d times, want 1", calls)
+	}
+	if stored != 7 {
+		t.Errorf("setter stored %v, want 7", stored)
+	}
+
+	if err := obj.DefineProperty("fixed", Descriptor{Value: ctx.NewNumberValue(3)}); err == nil {
+		t.Errorf("redefining a non-configurable property did not fail")
+	}
+	if err := obj.DefineProperty("bad", Descriptor{Get: func() int { return 1 }, Writable: true}); err == nil {
+		t.Errorf("an accessor descriptor with Writable did not fail")
+	}
+	if err := obj.DefineProperty("bad", Descriptor{Get: 42}); err == nil {
+		t.Errorf("a getter that is not a function did not fail")
+	}
+}
+
+func TestGetOwnPropertyDescriptor(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	ret, err := ctx.EvaluateScript("({a: 1, get b() { return 2; }})", nil, "./descriptor_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	obj := ret.ToObjectOrDie()
+
+	d, err := obj.GetOwnPropertyDescriptor("a")
+	if err != nil || d == nil {
+		t.Fatalf("obj.GetOwnPropertyDescriptor(\"a\") returned %v, %v", d, err)
+	}
+	if d.IsAccessor() || d.Value.ToNumberOrDie() != 1 || !d.Writable || !d.Enumerable || !d.Configurable {
+		t.Errorf("descriptor of a is %+v", d)
+	}
+
+	d, err = obj.GetOwnPropertyDescriptor("b")
+	if err != nil || d == nil {
+		t.Fatalf("obj.GetOwnPropertyDescriptor(\"b\") returned %v, %v", d, err)
+	}
+	get, ok := d.Get.(*Object)
+	if !ok || d.Set != nil || d.Value != nil {
+		t.Fatalf("descriptor of b is %+v", d)
+	}
+	if v, err := get.Call(obj); err != nil || v.ToNumberOrDie() != 2 {
+		t.Errorf("getter of b returned %v, %v", v, err)
+	}
+
+	if d, err := obj.GetOwnPropertyDescriptor("toString"); d != nil || err != nil {
+		t.Errorf("obj.GetOwnPropertyDescriptor of an inherited property returned %v, %v", d, err)
+	}
+}
+
+func TestFreezeAndSeal(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := ctx.NewEmptyObject()
+	obj.Set("a", ctx.NewNumberValue(1))
+	if !obj.IsExtensible() || obj.IsSealed() || obj.IsFrozen() {
+		t.Errorf("new object is not extensible or is sealed or frozen")
+	}
+
+	if err := obj.PreventExtensions(); err != nil {
+		t.Fatalf("obj.PreventExtensions returned an error (%v)", err)
+	}
+	obj.Set("b", ctx.NewNumberValue(2))
+	if obj.IsExtensible() || obj.Has("b") {
+		t.Errorf("obj.PreventExtensions did not stop a property being added")
+	}
+
+	if err := obj.Seal(); err != nil {
+		t.Fatalf("obj.Seal returned an error (%v)", err)
+	}
+	if ok, _ := obj.Delete("a"); ok || !obj.IsSealed() {
+		t.Errorf("obj.Seal did not stop a property being deleted")
+	}
+
+	if err := obj.Freeze(); err != nil {
+		t.Fatalf("obj.Freeze returned an error (%v)", err)
+	}
+	obj.Set("a", ctx.NewNumberValue(3))
+	if v, _ := obj.Get("a"); v.ToNumberOrDie() != 1 || !obj.IsFrozen() {
+		t.Errorf("obj.Freeze did not stop a property being changed")
+	}
+}
@@ -6,6 +6,7 @@ package gojs
 // #include <JavaScriptCore/JSValueRef.h>
 // #include "callback.h"
 import "C"
+import "errors"
 
 // NewError constructs a new JavaScript Error object with message.
 func (ctx *Context) NewError(message string) (*Object, error) {
@@ -18,6 +19,74 @@ func (ctx *Context) NewError(message string) (*Object, error) {
 	return ctx.newObject(ret), nil
 }
 
+// RangeError reports a number that does not fit the type it is converted
+// to. It is thrown to JavaScript as a RangeError.
+type RangeError struct {
+	Msg string
+}
+
+func (e *RangeError) Error() string {
+	return e.Msg
+}
+
+// TypeError reports a value of the wrong type for the Go value it is
+// converted to. It is thrown to JavaScript as a TypeError.
+type TypeError struct {
+	Msg string
+}
+
+func (e *TypeError) Error() string {
+	return e.Msg
+}
+
+// NewRangeError constructs a new JavaScript RangeError object with message.
+func (ctx *Context) NewRangeError(message string) (*Object, error) {
+	ret, err := ctx.callHelper("(function (RangeError) { return function (m) { return new RangeError(m); }; })(RangeError)", ctx.NewStringValue(message))
+	if err != nil {
+		return nil, err
+	}
+	return ret.ToObject()
+}
+
+// NewTypeError constructs a new JavaScript TypeError object with message.
+func (ctx *Context) NewTypeError(message string) (*Object, error) {
+	ret, err := ctx.callHelper("(function (TypeError) { return function (m) { return new TypeError(m); }; })(TypeError)", ctx.NewStringValue(message))
+	if err != nil {
+		return nil, err
+	}
+	return ret.ToObject()
+}
+
+// errorException returns the JavaScript counterpart of err: the thrown value
+// for an Exception, or a RangeError or TypeError for a *RangeError or
+// *TypeError. It returns nil for other errors.
+func (ctx *Context) errorException(err error) *Value {
+	var rangeErr *RangeError
+	var typeErr *TypeError
+	var exc Exception
+	switch {
+	case errors.As(err, &rangeErr):
+		if obj, err := ctx.NewRangeError(rangeErr.Error()); err == nil {
+			return obj.ToValue()
+		}
+	case errors.As(err, &typeErr):
+		if obj, err := ctx.NewTypeError(typeErr.Error()); err == nil {
+			return obj.ToValue()
+		}
+	case errors.As(err, &exc):
+		return exc.Value()
+	}
+	return nil
+}
+
+// exceptionFor returns the value to throw to JavaScript for err.
+func (ctx *Context) exceptionFor(err error) C.JSValueRef {
+	if ret := ctx.errorException(err); ret != nil {
+		return ret.ref
+	}
+	return ctx.newErrorOrPanic(err.Error())
+}
+
 func (ctx *Context) newErrorOrPanic(message string) C.JSValueRef {
 	obj, err := ctx.NewError(message)
 	if err != nil {
@@ -29,12 +98,20 @@ func (ctx *Context) newErrorOrPanic(message string) C.JSValueRef {
 	return C.JSValueRef(obj.ref)
 }
 
+// Exception is implemented by the errors returned when JavaScript code
+// throws. Value returns the thrown value, usually an Error object.
+type Exception interface {
+	error
+	Value() *Value
+}
+
 type errorValue struct {
 	ctx *Context
 	ref C.JSValueRef
 }
 
 func (ctx *Context) newErrorValue() *errorValue {
+	ctx.checkLive("")
 	return &errorValue{ctx, nil}
 }
 
@@ -53,5 +130,10 @@ func (r errorValue) Error() string {
 		panic("errorValue.ref is nil")
 	}
 	v := r.ctx.newValue(r.ref)
-	return r.ctx.ToStringOrDie(v)
+	return v.ToStringOrDie()
+}
+
+// Value returns the exception that was thrown.
+func (r errorValue) Value() *Value {
+	return r.ctx.newValue(r.ref)
 }
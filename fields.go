@@ -0,0 +1,164 @@
+package gojs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// embedded is a struct type reached through embedded fields, with the index
+// path that leads to it.
+type embedded struct {
+	typ   reflect.Type
+	index []int
+}
+
+// walkFields visits the fields of the struct type typ one depth at a time,
+// as Go resolves selectors: the fields declared in typ, then those promoted
+// from its embedded structs, and so on. A struct type reached at a shallower
+// depth is not visited again, but one reached along several paths at the
+// same depth is visited once per path, up to two, so that its fields show up
+// as ambiguous. visit is called with every field at a depth and its index
+// path; walkFields stops when visit returns false.
+func walkFields(typ reflect.Type, visit func(fields []reflect.StructField) bool) {
+	current := []embedded{{typ, nil}}
+	seen := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var fields []reflect.StructField
+		var next []embedded
+		count := map[reflect.Type]int{}
+		for _, e := range current {
+			if seen[e.typ] || count[e.typ] == 2 {
+				continue
+			}
+			count[e.typ]++
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				f.Index = append(append([]int(nil), e.index...), i)
+				fields = append(fields, f)
+				if !f.Anonymous {
+					continue
+				}
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, f.Index})
+				}
+			}
+		}
+		for t := range count {
+			seen[t] = true
+		}
+		if !visit(fields) {
+			return
+		}
+		current = next
+	}
+}
+
+// fieldTable maps the names that select fields of a struct type to the
+// fields they select.
+type fieldTable struct {
+	fields map[string]fieldEntry
+	// names lists the exported, unambiguous names, shallowest first.
+	names []string
+}
+
+// fieldEntry is the index path of a field, or the error selecting it.
+type fieldEntry struct {
+	index []int
+	err   error
+}
+
+// newFieldTable resolves every field name of the struct type typ following
+// Go's promotion rules: the shallowest field or method of a name wins, and
+// two at the same depth make the name ambiguous.
+//
+// Methods are resolved by Go itself: the method set of *typ holds a method,
+// declared or promoted, only where the selector denotes that method rather
+// than a field or nothing. Those names are left to the methods on the
+// prototype.
+func newFieldTable(typ reflect.Type) *fieldTable {
+	t := &fieldTable{fields: make(map[string]fieldEntry)}
+	methods := reflect.PointerTo(typ)
+	for i := 0; i < methods.NumMethod(); i++ {
+		t.fields[methods.Method(i).Name] = fieldEntry{err: errNoProperty}
+	}
+	walkFields(typ, func(fields []reflect.StructField) bool {
+		var order []string
+		matches := make(map[string][]reflect.StructField)
+		for _, f := range fields {
+			if _, ok := t.fields[f.Name]; ok {
+				continue
+			}
+			if matches[f.Name] == nil {
+				order = append(order, f.Name)
+			}
+			matches[f.Name] = append(matches[f.Name], f)
+		}
+		for _, name := range order {
+			switch m := matches[name]; {
+			case len(m) > 1:
+				paths := make([]string, len(m))
+				for i, f := range m {
+					paths[i] = selectorPath(typ, f.Index)
+				}
+				t.fields[name] = fieldEntry{err: &TypeError{fmt.Sprintf("gojs: ambiguous selector %s.%s: could be %s", typ, name, strings.Join(paths, " or "))}}
+			case m[0].IsExported():
+				t.fields[name] = fieldEntry{index: m[0].Index}
+				t.names = append(t.names, name)
+			default:
+				t.fields[name] = fieldEntry{err: errNoProperty}
+			}
+		}
+		return true
+	})
+	return t
+}
+
+// fieldPath resolves name to a field of the struct type typ. It returns
+// errNoProperty if there is no exported field of that name, and a
+// *TypeError if the name is ambiguous.
+func fieldPath(typ reflect.Type, name string) ([]int, error) {
+	f, ok := typeInfoFor(typ).fields.fields[name]
+	if !ok {
+		return nil, errNoProperty
+	}
+	return f.index, f.err
+}
+
+// selectorPath spells out the fields along index, as in "Inner.Name".
+func selectorPath(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, n := range index {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		f := typ.Field(n)
+		names[i] = f.Name
+		typ = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// structField returns the field name of the struct val, which may be
+// promoted from an embedded struct.
+func structField(val reflect.Value, name string) (reflect.Value, error) {
+	index, err := fieldPath(val.Type(), name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	field, err := val.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}, &TypeError{fmt.Sprintf("gojs: cannot reach %s.%s through a nil embedded pointer", val.Type(), selectorPath(val.Type(), index))}
+	}
+	return field, nil
+}
+
+// fieldNames lists the exported fields of the struct type typ that can be
+// selected by name, including promoted ones, shallowest first.
+func fieldNames(typ reflect.Type) []string {
+	return typeInfoFor(typ).fields.names
+}
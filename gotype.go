@@ -0,0 +1,107 @@
+package gojs
+
+// #include <stdlib.h>
+// #include <JavaScriptCore/JSObjectRef.h>
+// #include "callback.h"
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// GoObject returns the Go value wrapped by obj, if obj was created by
+// NewNativeObject or NewNativeObjectAs.
+func (obj *Object) GoObject() (interface{}, bool) {
+	data := obj.ctx.nativeObjectData(obj.ToValue())
+	if data == nil || !data.val.CanInterface() {
+		return nil, false
+	}
+	return data.val.Interface(), true
+}
+
+// UnwrapAs returns the Go value of type T behind the native object v. A
+// native object wrapping a pointer also gives the value it points to.
+func UnwrapAs[T any](v *Value) (T, bool) {
+	var ret T
+	dst := reflect.ValueOf(&ret).Elem()
+	src, ok := v.ctx.unwrapNative(v, dst.Type())
+	if !ok {
+		return ret, false
+	}
+	dst.Set(src)
+	return ret, true
+}
+
+// NewGoType returns a constructor standing for the Go type typ, for scripts
+// to test native objects with instanceof. A native object is an instance if
+// it wraps a value of type typ or a pointer to one, or, where typ is an
+// interface type, a value implementing it.
+//
+// Called with new, the constructor wraps a pointer to a new zero value of
+// typ, decoding its first argument, if any, into the value.
+func (ctx *Context) NewGoType(typ reflect.Type) *Object {
+	data := &object_data{
+		typ,
+		reflect.Value{},
+		0}
+	register(data)
+
+	ret := ctx.newObject(C.JSObjectMake(ctx.ref, nativetype, unsafe.Pointer(data)))
+	if typ.Kind() != reflect.Interface {
+		// The prototype of the objects the constructor creates.
+		if proto, err := ctx.nativePrototype(reflect.PointerTo(typ)); err == nil {
+			if err := ret.SetWithAttributes("prototype", proto.ToValue(), PropertyAttributeReadOnly|PropertyAttributeDontEnum|PropertyAttributeDontDelete); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return ret
+}
+
+// isInstance reports whether the Go value behind a native object is of type
+// typ or a pointer to one, or implements typ.
+func (data *object_data) isInstance(typ reflect.Type) bool {
+	for t := data.typ; ; t = t.Elem() {
+		if t == typ || typ.Kind() == reflect.Interface && t.Implements(typ) {
+			return true
+		}
+		if t.Kind() != reflect.Ptr {
+			return false
+		}
+	}
+}
+
+//export nativetype_HasInstance_go
+func nativetype_HasInstance_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, possibleInstance C.JSValueRef) C.bool {
+	ctx := NewContextFrom(RawContext(rawCtx))
+	typ := (*object_data)(data_ptr).typ
+	data := ctx.nativeObjectData(ctx.newValue(possibleInstance))
+	return C.bool(data != nil && data.isInstance(typ))
+}
+
+//export nativetype_CallAsConstructor_go
+func nativetype_CallAsConstructor_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, argumentCount uint, arguments unsafe.Pointer, exception *C.JSValueRef) (ret C.JSObjectRef) {
+	ctx := NewContextFrom(RawContext(rawCtx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = panicToException(ctx, r).ref
+			ret = nil
+		}
+	}()
+	ctx.checkTerminated()
+
+	typ := (*object_data)(data_ptr).typ
+	if typ.Kind() == reflect.Interface {
+		panic(&TypeError{fmt.Sprintf("gojs: cannot construct a value of interface type %s", typ)})
+	}
+	ptr := reflect.New(typ)
+	if args := ctx.newGoValueArray(arguments, argumentCount); len(args) > 0 && !args[0].IsUndefined() {
+		v, err := ctx.toGoValue(args[0], typ, "argument 1")
+		if err != nil {
+			panic(err)
+		}
+		ptr.Elem().Set(v)
+	}
+	return ctx.newNativeObject(ptr).ref
+}
@@ -0,0 +1,107 @@
+package gojs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type gotype_person struct {
+	Name string
+	Age  int
+}
+
+func (p *gotype_person) String() string {
+	return p.Name
+}
+
+func TestObjectGoObject(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	p := &gotype_person{Name: "Ann"}
+	obj := ctx.NewNativeObject(p)
+	if got, ok := obj.GoObject(); !ok || got != p {
+		t.Errorf("obj.GoObject() = %v, %v, want %p, true", got, ok, p)
+	}
+	if _, ok := ctx.NewEmptyObject().GoObject(); ok {
+		t.Errorf("obj.GoObject() succeeded for a plain object")
+	}
+
+	if got, ok := UnwrapAs[*gotype_person](obj.ToValue()); !ok || got != p {
+		t.Errorf("UnwrapAs[*gotype_person] = %v, %v, want %p, true", got, ok, p)
+	}
+	if got, ok := UnwrapAs[gotype_person](obj.ToValue()); !ok || got != *p {
+		t.Errorf("UnwrapAs[gotype_person] = %v, %v, want %v, true", got, ok, *p)
+	}
+	if got, ok := UnwrapAs[Stringer](obj.ToValue()); !ok || got != Stringer(p) {
+		t.Errorf("UnwrapAs[Stringer] = %v, %v, want %p, true", got, ok, p)
+	}
+	if _, ok := UnwrapAs[*reflect_object](obj.ToValue()); ok {
+		t.Errorf("UnwrapAs[*reflect_object] succeeded for a *gotype_person")
+	}
+}
+
+func TestNativeFunctionUnwrap(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	p := &gotype_person{Name: "Ann"}
+	var got *gotype_person
+	global := ctx.GlobalObject()
+	global.Set("p", ctx.NewNativeObject(p).ToValue())
+	global.Set("take", ctx.NewFunctionWithNative(func(q *gotype_person) { got = q }).ToValue())
+	global.Set("name", ctx.NewFunctionWithNative(func(s Stringer) string { return s.String() }).ToValue())
+	global.Set("age", ctx.NewFunctionWithNative(func(q gotype_person) int { return q.Age }).ToValue())
+
+	if _, err := ctx.EvaluateScript("take(p)", nil, "", 1); err != nil {
+		t.Fatalf("take(p) failed: %v", err)
+	}
+	if got != p {
+		t.Errorf("take(p) received %p, want %p", got, p)
+	}
+	ret, err := ctx.EvaluateScript("name(p) + ' ' + age({Name: 'Bob', Age: 3})", nil, "", 1)
+	if err != nil || ret.String() != "Ann 3" {
+		t.Errorf("name(p) + age(...) = %v, %v, want \"Ann 3\"", ret, err)
+	}
+	if _, err := ctx.EvaluateScript("name({})", nil, "", 1); err == nil {
+		t.Errorf("name({}) did not throw")
+	}
+}
+
+func TestNewGoType(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	global := ctx.GlobalObject()
+	global.Set("Person", ctx.NewGoType(reflect.TypeOf(gotype_person{})).ToValue())
+	global.Set("Stringer", ctx.NewGoType(reflect.TypeOf((*Stringer)(nil)).Elem()).ToValue())
+	global.Set("Point", ctx.NewGoType(reflect.TypeOf(nested_point{})).ToValue())
+	global.Set("p", ctx.NewNativeObject(&gotype_person{Name: "Ann"}).ToValue())
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"p instanceof Person", "true"},
+		{"p instanceof Stringer", "true"},
+		{"p instanceof Point", "false"},
+		{"({}) instanceof Person", "false"},
+		{"var q = new Person({Name: 'Bob'}); q.Name + ' ' + (q instanceof Person)", "Bob true"},
+		{"String(new Person({Name: 'Cy'}))", "Cy"},
+		{"new Point().X", "0"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+
+	if _, err := ctx.EvaluateScript("new Stringer()", nil, "", 1); err == nil {
+		t.Errorf("new Stringer() did not throw")
+	}
+}
@@ -0,0 +1,584 @@
+package gojs
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// InspectOptions controls the output of Value.Inspect.
+type InspectOptions struct {
+	// Depth is the number of nested levels of objects that are expanded.
+	// Deeper objects are abbreviated to [Object], [Array] and so on. A
+	// negative depth expands everything.
+	Depth int
+
+	// ShowHidden includes non-enumerable properties, shown in brackets.
+	ShowHidden bool
+
+	// MaxArrayLength is the number of elements shown for arrays, maps and
+	// sets before the rest are summarised. Zero shows none of them; a
+	// negative value shows all.
+	MaxArrayLength int
+
+	// BreakLength is the line length above which objects are printed with
+	// one property per line. Zero or less keeps every object on one line.
+	BreakLength int
+}
+
+// DefaultInspectOptions are the options used by Inspect when it is passed
+// nil. They match the defaults of Node's util.inspect.
+var DefaultInspectOptions = InspectOptions{
+	Depth:          2,
+	MaxArrayLength: 100,
+	BreakLength:    80,
+}
+
+// Inspect returns a human readable rendering of v in the style of Node's
+// util.inspect. Unlike String and JSON it never fails: conversions that
+// throw, as methods redefined by the script may, fall back to a generic
+// form, nested objects are cut off at opts.Depth, cycles are marked with
+// [Circular *n], and functions, dates, regular expressions, errors, maps,
+// sets, arrays with holes and Go native objects all get a descriptive form.
+func (v *Value) Inspect(opts *InspectOptions) string {
+	if opts == nil {
+		opts = &DefaultInspectOptions
+	}
+	in := &inspector{ctx: v.ctx, opts: opts, refs: make(map[*Value]int)}
+	return in.value(v, 0, 0)
+}
+
+type inspector struct {
+	ctx  *Context
+	opts *InspectOptions
+
+	// parents is the chain of objects being rendered, used to find cycles.
+	parents []*Value
+	// refs numbers the objects that are the target of a cycle.
+	refs map[*Value]int
+}
+
+func (in *inspector) value(v *Value, depth, indent int) string {
+	ctx := in.ctx
+	switch v.Type() {
+	case TypeUndefined:
+		return "undefined"
+	case TypeNull:
+		return "null"
+	case TypeBoolean:
+		return strconv.FormatBool(v.ToBoolean())
+	case TypeNumber:
+		num, _ := v.ToNumber()
+		return inspectNumber(num)
+	case TypeString:
+		return quoteJS(inspectString(v, ""))
+	case TypeBigInt:
+		return inspectString(v, "") + "n"
+	case TypeSymbol:
+		if ret, err := ctx.callHelper("(function (String) { return function (s) { return String(s); }; })(String)", v); err == nil {
+			return inspectString(ret, "Symbol()")
+		}
+		return "Symbol()"
+	}
+
+	obj, err := v.ToObject()
+	if err != nil {
+		return inspectString(v, "[unknown]")
+	}
+
+	for _, parent := range in.parents {
+		if parent.StrictEquals(v) {
+			n, ok := in.refs[parent]
+			if !ok {
+				n = len(in.refs) + 1
+				in.refs[parent] = n
+			}
+			return "[Circular *" + strconv.Itoa(n) + "]"
+		}
+	}
+
+	if typ := ctx.nativeFunctionType(v); typ != "" {
+		return "[Go " + typ + "]"
+	}
+
+	class := in.class(v)
+	switch class {
+	case "Function":
+		return in.function(obj)
+	case "Date":
+		if ret, err := ctx.callHelper(`(function (isNaN, getTime, toISOString) {
+			return function (d) { return isNaN(getTime.call(d)) ? 'Invalid Date' : toISOString.call(d); };
+		})(isNaN, Date.prototype.getTime, Date.prototype.toISOString)`, v); err == nil {
+			return inspectString(ret, "[Date]")
+		}
+		return "[Date]"
+	case "RegExp":
+		if ret, err := ctx.callHelper("(function (toString) { return function (r) { return toString.call(r); }; })(RegExp.prototype.toString)", v); err == nil {
+			return inspectString(ret, "[RegExp]")
+		}
+		return "[RegExp]"
+	case "Error":
+		return in.error(obj)
+	case "Number", "String", "Boolean":
+		if ret, err := ctx.callHelper(`(function (valueOf) {
+			return function (o, c) { return valueOf[c].call(o); };
+		})({ Number: Number.prototype.valueOf, String: String.prototype.valueOf, Boolean: Boolean.prototype.valueOf })`, v, ctx.NewStringValue(class)); err == nil {
+			return "[" + class + ": " + in.value(ret, depth, indent) + "]"
+		}
+	}
+
+	data := ctx.nativeObjectData(v)
+	prefix := ""
+	switch {
+	case data != nil:
+		prefix = data.typ.String()
+	case class == "Array":
+	case class == "Map" || class == "Set":
+		size, _ := obj.Get("size")
+		prefix = class + "(" + inspectString(size, "?") + ")"
+	case class == "Object":
+		if name := in.constructorName(v); name != "" && name != "Object" {
+			prefix = name
+		}
+	default:
+		prefix = class
+	}
+
+	if in.opts.Depth >= 0 && depth > in.opts.Depth {
+		switch {
+		case class == "Array":
+			return "[Array]"
+		case prefix != "":
+			return "[" + prefix + "]"
+		}
+		return "[Object]"
+	}
+
+	in.parents = append(in.parents, v)
+	var items []string
+	open, close := "{", "}"
+	switch {
+	case data != nil:
+		if k := data.elem().Kind(); k == reflect.Slice || k == reflect.Array {
+			open, close = "[", "]"
+			items = in.arrayItems(obj, depth, indent)
+			break
+		}
+		items = in.nativeFields(obj, data, depth, indent)
+	case class == "Array":
+		open, close = "[", "]"
+		items = in.arrayItems(obj, depth, indent)
+	case class == "Map" || class == "Set":
+		items = in.collectionItems(v, class == "Map", depth, indent)
+	default:
+		items = in.properties(v, nil, depth, indent)
+	}
+	in.parents = in.parents[:len(in.parents)-1]
+
+	out := in.join(open, close, items, indent)
+	if prefix != "" {
+		out = prefix + " " + out
+	}
+	if n, ok := in.refs[v]; ok {
+		out = "<ref *" + strconv.Itoa(n) + "> " + out
+	}
+	return out
+}
+
+// join lays out items between open and close, on one line if they fit in
+// BreakLength and one per line otherwise.
+func (in *inspector) join(open, close string, items []string, indent int) string {
+	if len(items) == 0 {
+		return open + close
+	}
+	line := open + " " + strings.Join(items, ", ") + " " + close
+	if in.opts.BreakLength <= 0 || (indent+utf8.RuneCountInString(line) <= in.opts.BreakLength && !strings.Contains(line, "\n")) {
+		return line
+	}
+	pad := strings.Repeat(" ", indent+2)
+	return open + "\n" + pad + strings.Join(items, ",\n"+pad) + "\n" + strings.Repeat(" ", indent) + close
+}
+
+// class returns the built-in class of v as reported by
+// Object.prototype.toString, such as "Array" or "Date".
+func (in *inspector) class(v *Value) string {
+	ret, err := in.ctx.callHelper("(function (toString) { return function (o) { return toString.call(o); }; })(Object.prototype.toString)", v)
+	if err != nil {
+		return "Object"
+	}
+	tag := inspectString(ret, "[object Object]")
+	return strings.TrimSuffix(strings.TrimPrefix(tag, "[object "), "]")
+}
+
+func (in *inspector) constructorName(v *Value) string {
+	ret, err := in.ctx.callHelper("function (o) { var c = o.constructor; return typeof c === 'function' && typeof c.name === 'string' ? c.name : ''; }", v)
+	if err != nil {
+		return ""
+	}
+	return inspectString(ret, "")
+}
+
+func (in *inspector) function(obj *Object) string {
+	ctx := in.ctx
+	ret, err := ctx.callHelper(`(function (toString, test) {
+		return function (f) {
+			var s = toString.call(f);
+			return [test.call(/^class\b/, s), typeof f.name === 'string' ? f.name : ''];
+		};
+	})(Function.prototype.toString, RegExp.prototype.test)`, obj.ToValue())
+	if err != nil {
+		return "[Function]"
+	}
+	info, err := ret.ToObject()
+	if err != nil {
+		return "[Function]"
+	}
+	isClass, _ := info.Get("0")
+	name, _ := info.Get("1")
+
+	kind := "Function"
+	if isClass.ToBoolean() {
+		kind = "class"
+	}
+	if n := inspectString(name, ""); n != "" {
+		if kind == "class" {
+			return "[class " + n + "]"
+		}
+		return "[Function: " + n + "]"
+	}
+	return "[" + kind + " (anonymous)]"
+}
+
+// error renders an Error the way Node prints its stack: the string form of
+// the error followed by one "at" line per frame.
+func (in *inspector) error(obj *Object) string {
+	out := "Error"
+	if ret, err := in.ctx.callHelper("(function (toString) { return function (e) { return toString.call(e); }; })(Error.prototype.toString)", obj.ToValue()); err == nil {
+		out = inspectString(ret, out)
+	}
+	stack, err := obj.Get("stack")
+	if err != nil || !stack.IsString() {
+		return "[" + out + "]"
+	}
+	for _, frame := range strings.Split(inspectString(stack, ""), "\n") {
+		if frame == "" {
+			continue
+		}
+		// JavaScriptCore writes frames as "function@location".
+		if i := strings.LastIndexByte(frame, '@'); i >= 0 {
+			frame = frame[:i] + " (" + frame[i+1:] + ")"
+		}
+		out += "\n    at " + frame
+	}
+	return out
+}
+
+func (in *inspector) arrayItems(obj *Object, depth, indent int) []string {
+	ctx := in.ctx
+	n := inspectLength(obj)
+	limit := n
+	if in.opts.MaxArrayLength >= 0 && limit > in.opts.MaxArrayLength {
+		limit = in.opts.MaxArrayLength
+	}
+
+	var items []string
+	holes := 0
+	flush := func() {
+		if holes == 1 {
+			items = append(items, "<1 empty item>")
+		} else if holes > 1 {
+			items = append(items, "<"+strconv.Itoa(holes)+" empty items>")
+		}
+		holes = 0
+	}
+	for i := 0; i < limit; i++ {
+		name := strconv.Itoa(i)
+		if !obj.Has(name) {
+			holes++
+			continue
+		}
+		flush()
+		item, err := obj.Get(name)
+		if err != nil {
+			item = ctx.NewUndefinedValue()
+		}
+		items = append(items, in.value(item, depth+1, indent+2))
+	}
+	flush()
+	if limit < n {
+		items = append(items, "... "+strconv.Itoa(n-limit)+" more item"+plural(n-limit))
+	}
+
+	// Properties that are not indices, such as those added to the result
+	// of RegExp.prototype.exec.
+	return in.properties(obj.ToValue(), items, depth, indent)
+}
+
+func (in *inspector) collectionItems(v *Value, isMap bool, depth, indent int) []string {
+	ctx := in.ctx
+	ret, err := ctx.callHelper(`(function (from, entries, values) {
+		return function (c, isMap) { return from(isMap ? entries.call(c) : values.call(c)); };
+	})(Array.from, Map.prototype.entries, Set.prototype.values)`, v, ctx.NewBooleanValue(isMap))
+	if err != nil {
+		return nil
+	}
+	entries, err := ret.ToObject()
+	if err != nil {
+		return nil
+	}
+	n := inspectLength(entries)
+	limit := n
+	if in.opts.MaxArrayLength >= 0 && limit > in.opts.MaxArrayLength {
+		limit = in.opts.MaxArrayLength
+	}
+
+	var items []string
+	for i := 0; i < limit; i++ {
+		entry, err := entries.Get(strconv.Itoa(i))
+		if err != nil {
+			continue
+		}
+		if !isMap {
+			items = append(items, in.value(entry, depth+1, indent+2))
+			continue
+		}
+		pair, err := entry.ToObject()
+		if err != nil {
+			continue
+		}
+		key, _ := pair.Get("0")
+		val, _ := pair.Get("1")
+		items = append(items, in.value(key, depth+1, indent+2)+" => "+in.value(val, depth+1, indent+2))
+	}
+	if limit < n {
+		items = append(items, "... "+strconv.Itoa(n-limit)+" more item"+plural(n-limit))
+	}
+	return items
+}
+
+// properties appends the own properties of v to items. Array indices are
+// skipped, since arrayItems has already rendered them.
+func (in *inspector) properties(v *Value, items []string, depth, indent int) []string {
+	ctx := in.ctx
+	obj, err := v.ToObject()
+	if err != nil {
+		return items
+	}
+
+	keysFn := "Object.keys"
+	if in.opts.ShowHidden {
+		keysFn = "Object.getOwnPropertyNames"
+	}
+	enumerable := map[string]bool{}
+	names := in.strings(keysFn, v)
+	if in.opts.ShowHidden {
+		for _, name := range in.strings("Object.keys", v) {
+			enumerable[name] = true
+		}
+	}
+
+	isArray := in.class(v) == "Array"
+	for _, name := range names {
+		if isArray && isArrayIndex(name) {
+			continue
+		}
+		item, err := obj.Get(name)
+		if err != nil {
+			item = ctx.NewUndefinedValue()
+		}
+		key := inspectKey(name)
+		if in.opts.ShowHidden && !enumerable[name] {
+			key = "[" + key + "]"
+		}
+		items = append(items, key+": "+in.value(item, depth+1, indent+2))
+	}
+	return in.symbolProperties(v, items, depth, indent)
+}
+
+// symbolProperties appends the own symbol-keyed properties of v to items.
+func (in *inspector) symbolProperties(v *Value, items []string, depth, indent int) []string {
+	ret, err := in.ctx.callHelper(`(function (symbols, describe, String) {
+		return function (o, all) {
+			if (typeof symbols !== 'function') return [];
+			var keys = symbols(o), props = [];
+			for (var i = 0; i < keys.length; i++) {
+				var enumerable = describe(o, keys[i]).enumerable;
+				if (all || enumerable) props[props.length] = [String(keys[i]), o[keys[i]], enumerable];
+			}
+			return props;
+		};
+	})(Object.getOwnPropertySymbols, Object.getOwnPropertyDescriptor, String)`, v, in.ctx.NewBooleanValue(in.opts.ShowHidden))
+	if err != nil {
+		return items
+	}
+	props, err := ret.ToObject()
+	if err != nil {
+		return items
+	}
+	n := inspectLength(props)
+	for i := 0; i < n; i++ {
+		prop, err := props.Get(strconv.Itoa(i))
+		if err != nil {
+			continue
+		}
+		p, err := prop.ToObject()
+		if err != nil {
+			continue
+		}
+		name, _ := p.Get("0")
+		item, _ := p.Get("1")
+		enumerable, _ := p.Get("2")
+		key := "[" + inspectString(name, "Symbol()") + "]"
+		if !enumerable.ToBoolean() {
+			key = "[" + key + "]"
+		}
+		items = append(items, key+": "+in.value(item, depth+1, indent+2))
+	}
+	return items
+}
+
+// nativeFields renders the exported fields or map entries of the Go value
+// behind a native object, read through the object so that they are
+// converted the same way scripts see them.
+func (in *inspector) nativeFields(obj *Object, data *object_data, depth, indent int) []string {
+	var items []string
+	for _, name := range data.propertyNames() {
+		item, err := obj.Get(name)
+		if err != nil || item == nil {
+			continue
+		}
+		items = append(items, inspectKey(name)+": "+in.value(item, depth+1, indent+2))
+	}
+	return items
+}
+
+// strings calls the helper fn with v and returns the resulting array of
+// strings.
+func (in *inspector) strings(fn string, v *Value) []string {
+	ctx := in.ctx
+	ret, err := ctx.callHelper(fn, v)
+	if err != nil {
+		return nil
+	}
+	arr, err := ret.ToObject()
+	if err != nil {
+		return nil
+	}
+	items, err := arr.ToValueSlice()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = inspectString(item, "")
+	}
+	return names
+}
+
+// inspectString converts v to a string, or returns fallback if that throws.
+func inspectString(v *Value, fallback string) string {
+	if v == nil {
+		return fallback
+	}
+	str, err := v.ToString()
+	if err != nil {
+		return fallback
+	}
+	return str
+}
+
+// inspectLength returns the length of the array-like obj, or 0 if it has
+// no usable length.
+func inspectLength(obj *Object) int {
+	length, err := obj.Get("length")
+	if err != nil || length == nil {
+		return 0
+	}
+	n, err := length.ToNumber()
+	if err != nil || !(n > 0) {
+		return 0
+	}
+	return int(math.Min(n, math.MaxUint32))
+}
+
+func inspectNumber(num float64) string {
+	switch {
+	case math.IsInf(num, 1):
+		return "Infinity"
+	case math.IsInf(num, -1):
+		return "-Infinity"
+	case math.IsNaN(num):
+		return "NaN"
+	case num == 0 && math.Signbit(num):
+		return "-0"
+	}
+	return strconv.FormatFloat(num, 'g', -1, 64)
+}
+
+// quoteJS quotes s as a JavaScript string literal, preferring single quotes
+// like Node does.
+func quoteJS(s string) string {
+	quote := byte('\'')
+	if strings.IndexByte(s, '\'') >= 0 && strings.IndexByte(s, '"') < 0 {
+		quote = '"'
+	}
+
+	var b strings.Builder
+	b.WriteByte(quote)
+	for _, r := range s {
+		switch r {
+		case rune(quote), '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(`\x`)
+				b.WriteString(strconv.FormatInt(int64(r)|0x100, 16)[1:])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+// inspectKey quotes property names that are not valid identifiers.
+func inspectKey(name string) string {
+	if name == "" {
+		return "''"
+	}
+	for i, r := range name {
+		if r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return quoteJS(name)
+	}
+	return name
+}
+
+func isArrayIndex(name string) bool {
+	n, err := strconv.ParseUint(name, 10, 32)
+	return err == nil && n < math.MaxUint32 && strconv.FormatUint(n, 10) == name
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,150 @@
+package gojs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"undefined", "undefined"},
+		{"null", "null"},
+		{"true", "true"},
+		{"1.5", "1.5"},
+		{"-0", "-0"},
+		{"NaN", "NaN"},
+		{"'it\\'s'", `"it's"`},
+		{"'line\\n'", `'line\n'`},
+		{"({})", "{}"},
+		{"({a: 1, 'b-c': 'x', d: [1, 2]})", "{ a: 1, 'b-c': 'x', d: [ 1, 2 ] }"},
+		{"({a: {b: {c: {d: 1}}}})", "{ a: { b: { c: [Object] } } }"},
+		{"[[[[1]]]]", "[ [ [ [Array] ] ] ]"},
+		{"var o = {}; o.self = o; o", "<ref *1> { self: [Circular *1] }"},
+		{"[1, , , 4]", "[ 1, <2 empty items>, 4 ]"},
+		{"(function foo() {})", "[Function: foo]"},
+		{"(function () {})", "[Function (anonymous)]"},
+		{"new Date(0)", "1970-01-01T00:00:00.000Z"},
+		{"new Date(NaN)", "Invalid Date"},
+		{"/a+b/g", "/a+b/g"},
+		{"new Number(3)", "[Number: 3]"},
+		{"function Point(x) { this.x = x; } new Point(1)", "Point { x: 1 }"},
+		{"new Map([['a', 1]])", "Map(1) { 'a' => 1 }"},
+		{"new Set([1, 2])", "Set(2) { 1, 2 }"},
+	}
+
+	for _, test := range tests {
+		v, err := ctx.EvaluateScript(test.script, nil, "./inspect_test.go", 1)
+		if err != nil {
+			t.Errorf("%s: ctx.EvaluateScript returned an error (%v)", test.script, err)
+			continue
+		}
+		if got := v.Inspect(nil); got != test.want {
+			t.Errorf("%s: v.Inspect(nil) returned %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestInspectOptions(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript("[1, 2, 3, 4]", nil, "./inspect_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if got := v.Inspect(&InspectOptions{MaxArrayLength: 2}); got != "[ 1, 2, ... 2 more items ]" {
+		t.Errorf("v.Inspect with MaxArrayLength returned %q", got)
+	}
+	if got := v.Inspect(&InspectOptions{Depth: 2, MaxArrayLength: -1, ShowHidden: true}); got != "[ 1, 2, 3, 4, [length]: 4 ]" {
+		t.Errorf("v.Inspect with ShowHidden returned %q", got)
+	}
+
+	v, err = ctx.EvaluateScript("({a: {b: {c: {d: 1}}}})", nil, "./inspect_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if got := v.Inspect(&InspectOptions{Depth: -1}); got != "{ a: { b: { c: { d: 1 } } } }" {
+		t.Errorf("v.Inspect with unlimited depth returned %q", got)
+	}
+
+	v, err = ctx.EvaluateScript("({first: 'aaaaaaaaaa', second: 'bbbbbbbbbb'})", nil, "./inspect_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	want := "{\n  first: 'aaaaaaaaaa',\n  second: 'bbbbbbbbbb'\n}"
+	if got := v.Inspect(&InspectOptions{Depth: 2, BreakLength: 20}); got != want {
+		t.Errorf("v.Inspect with BreakLength returned %q, want %q", got, want)
+	}
+}
+
+func TestInspectError(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript("function fail() { return new Error('boom'); }\nfail()", nil, "./inspect_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	got := v.Inspect(nil)
+	if !strings.HasPrefix(got, "Error: boom\n    at fail (") {
+		t.Errorf("v.Inspect returned %q for an error", got)
+	}
+}
+
+func TestInspectNative(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := ctx.NewNativeObject(&reflect_object{-1, 2, 3, "four"})
+	if got, want := obj.ToValue().Inspect(nil), "*gojs.reflect_object { I: -1, U: 2, F: 3, S: 'four' }"; got != want {
+		t.Errorf("Inspect of a native object returned %q, want %q", got, want)
+	}
+
+	fn := ctx.NewFunctionWithNative(func(a, b float64) float64 { return a + b })
+	if got, want := fn.ToValue().Inspect(nil), "[Go func(float64, float64) float64]"; got != want {
+		t.Errorf("Inspect of a native function returned %q, want %q", got, want)
+	}
+}
+
+func TestInspectPatchedBuiltins(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	script := "[{a: 1}, new Map([[1, 2]]), new Set([3]), new Date(0), /x/, new Number(4), function f() {}, Symbol('s')]"
+	v, err := ctx.EvaluateScript(script, nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	want := v.Inspect(nil)
+
+	_, err = ctx.EvaluateScript(`
+		Object.keys = function () { return ['bogus']; };
+		Array.from = function () { throw new Error('patched'); };
+		Object.prototype.toString = function () { return '[object Patched]'; };
+		Date.prototype.toISOString = function () { throw new Error('patched'); };
+		RegExp.prototype.toString = function () { throw new Error('patched'); };
+		Number.prototype.valueOf = function () { return 0; };
+		Function.prototype.toString = function () { return 'class {}'; };
+		String = function () { return 'patched'; };
+	`, nil, "", 1)
+	if err != nil {
+		t.Fatalf("patching built-ins failed: %v", err)
+	}
+	if got := v.Inspect(nil); got != want {
+		t.Errorf("v.Inspect() after patching built-ins = %q, want %q", got, want)
+	}
+
+	v, err = ctx.EvaluateScript("var e = new Error('x'); e.toString = function () { throw 1; }; [e, {toString: function () { throw 2; }}]", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if got := v.Inspect(nil); !strings.Contains(got, "Error: x") {
+		t.Errorf("v.Inspect() of objects with throwing toString = %q", got)
+	}
+}
@@ -0,0 +1,299 @@
+package gojs
+
+import (
+	"errors"
+	"iter"
+	"reflect"
+	"runtime"
+)
+
+// StopIteration can be returned by the function passed to Iterate to stop
+// early without Iterate returning an error.
+var StopIteration = errors.New("gojs: stop iteration")
+
+// Iterate calls fn for each value produced by the JavaScript iteration
+// protocol on v, as a for...of loop would. Arrays, strings, Maps, Sets,
+// generators and any object with a Symbol.iterator method can be iterated;
+// array-like objects are walked by index on JavaScriptCore versions without
+// symbols.
+//
+// If fn returns an error, the iterator is closed and Iterate returns the
+// error, or nil for StopIteration.
+func (v *Value) Iterate(fn func(v *Value) error) error {
+	ctx := v.ctx
+	it, err := ctx.callHelper(`(function (iterator, TypeError) {
+		return function (o) {
+			if (iterator && o != null && typeof o[iterator] === 'function')
+				return o[iterator]();
+			if (o != null && typeof o.length === 'number') {
+				var i = 0;
+				return { next: function () { return i < o.length ? { done: false, value: o[i++] } : { done: true }; } };
+			}
+			throw new TypeError('value is not iterable');
+		};
+	})(typeof Symbol === 'function' ? Symbol.iterator : undefined, TypeError)`, v)
+	if err != nil {
+		return err
+	}
+
+	for {
+		step, err := ctx.callHelper(`(function (Object, TypeError) {
+			return function (it) {
+				var r = it.next();
+				if (Object(r) !== r) throw new TypeError('iterator result is not an object');
+				return r.done ? undefined : [r.value];
+			};
+		})(Object, TypeError)`, it)
+		if err != nil {
+			return err
+		}
+		if step.IsUndefined() {
+			return nil
+		}
+		item, err := step.ToObjectOrDie().GetIndex(0)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			ctx.callHelper("function (it) { if (typeof it['return'] === 'function') it['return'](); }", it)
+			if err == StopIteration {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Iterator is a sequence of Go values that JavaScript can iterate over.
+// Next returns the next value, or false once the sequence is exhausted.
+type Iterator interface {
+	Next() (value interface{}, ok bool)
+}
+
+// Iterable is implemented by Go values that provide an Iterator.
+//
+// A native object is iterable from JavaScript, with for...of and spread, if
+// its Go value implements Iterable, has a Range method in the shape of an
+// iter.Seq or iter.Seq2, or is a slice, an array or a channel. Pairs from a
+// Seq2 are produced as [key, value] arrays, and channels are received from
+// until they are closed.
+type Iterable interface {
+	Iterator() Iterator
+}
+
+// goIterator adapts the supported kinds of Go sequence to a single shape.
+// next returns one value, or two for key-value pairs. stop, if set, releases
+// the goroutine behind a Range method; it also runs when an abandoned
+// iterator is collected.
+type goIterator struct {
+	next func() ([]reflect.Value, bool)
+	stop func()
+}
+
+var iterableType = reflect.TypeOf((*Iterable)(nil)).Elem()
+
+// isIterableType reports whether native objects of type typ are iterable.
+func isIterableType(typ reflect.Type) bool {
+	if typ.Implements(iterableType) {
+		return true
+	}
+	if m, ok := typ.MethodByName("Range"); ok && isRangeFunc(m.Type, 1) {
+		return true
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	case reflect.Chan:
+		return typ.ChanDir()&reflect.RecvDir != 0
+	}
+	return false
+}
+
+// isRangeFunc reports whether typ, with in receiver arguments, has the shape
+// func(yield func(V) bool) or func(yield func(K, V) bool).
+func isRangeFunc(typ reflect.Type, in int) bool {
+	if typ.NumIn() != in+1 || typ.NumOut() != 0 {
+		return false
+	}
+	yield := typ.In(in)
+	return yield.Kind() == reflect.Func &&
+		(yield.NumIn() == 1 || yield.NumIn() == 2) &&
+		yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+}
+
+func newGoIterator(val reflect.Value) *goIterator {
+	if it, ok := val.Interface().(Iterable); ok {
+		i := it.Iterator()
+		return &goIterator{next: func() ([]reflect.Value, bool) {
+			v, ok := i.Next()
+			return []reflect.Value{reflect.ValueOf(v)}, ok
+		}}
+	}
+
+	if m := val.MethodByName("Range"); m.IsValid() && isRangeFunc(m.Type(), 0) {
+		yieldType := m.Type().In(0)
+		seq := func(yield func([]reflect.Value) bool) {
+			fn := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.ValueOf(yield(args))}
+			})
+			m.Call([]reflect.Value{fn})
+		}
+		next, stop := iter.Pull(seq)
+		it := &goIterator{next: next, stop: stop}
+		// The JavaScript iterator holds the only references to it, so it
+		// becomes unreachable once the script drops the iterator without
+		// exhausting or returning it.
+		runtime.SetFinalizer(it, func(it *goIterator) { it.stop() })
+		return it
+	}
+
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		i := 0
+		return &goIterator{next: func() ([]reflect.Value, bool) {
+			if i >= val.Len() {
+				return nil, false
+			}
+			i++
+			return []reflect.Value{val.Index(i - 1)}, true
+		}}
+	case reflect.Chan:
+		return &goIterator{next: func() ([]reflect.Value, bool) {
+			v, ok := val.Recv()
+			return []reflect.Value{v}, ok
+		}}
+	}
+	return nil
+}
+
+// newJSIterator returns a JavaScript iterator object that draws its values
+// from it.
+func (ctx *Context) newJSIterator(it *goIterator) (*Value, error) {
+	done := false
+	stop := func() {
+		if !done && it.stop != nil {
+			it.stop()
+		}
+		done = true
+	}
+
+	next := func(ctx *Context, _, _ *Object, _ []*Value) *Value {
+		if done {
+			return ctx.NewUndefinedValue()
+		}
+		values, ok := it.next()
+		if !ok {
+			stop()
+			return ctx.NewUndefinedValue()
+		}
+		var value *Value
+		if len(values) == 1 {
+			value = ctx.iteratedValue(values[0])
+		} else {
+			pair, err := ctx.NewArray([]*Value{ctx.iteratedValue(values[0]), ctx.iteratedValue(values[1])})
+			if err != nil {
+				panic(err)
+			}
+			value = pair.ToValue()
+		}
+		ret, err := ctx.NewArray([]*Value{value})
+		if err != nil {
+			panic(err)
+		}
+		return ret.ToValue()
+	}
+	closer := func(ctx *Context, _, _ *Object, _ []*Value) *Value {
+		stop()
+		return nil
+	}
+
+	return ctx.callHelper(`(function (iterator) {
+		return function (next, stop) {
+			var it = {
+				next: function () {
+					var r = next();
+					return r === undefined ? { done: true, value: undefined } : { done: false, value: r[0] };
+				},
+				'return': function (value) {
+					stop();
+					return { done: true, value: value };
+				}
+			};
+			if (iterator) it[iterator] = function () { return this; };
+			return it;
+		};
+	})(typeof Symbol === 'function' ? Symbol.iterator : undefined)`, ctx.NewFunctionWithCallback(next).ToValue(), ctx.NewFunctionWithCallback(closer).ToValue())
+}
+
+func (ctx *Context) iteratedValue(v reflect.Value) *Value {
+	if !v.IsValid() {
+		return ctx.NewNullValue()
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ctx.NewNullValue()
+		}
+		v = v.Elem()
+	}
+	return ctx.reflectToJSValue(v)
+}
+
+// iterablePrototype returns the prototype given to iterable native objects,
+// whose Symbol.iterator method iterates over the Go value. It is created once
+// per context, protected until the context is released, and is nil if the
+// context does not support symbols.
+func (ctx *Context) iterablePrototype() *Object {
+	s := ctx.state()
+	s.mu.Lock()
+	proto := s.iterable
+	s.mu.Unlock()
+	if proto != nil {
+		return ctx.newObject(proto.ref)
+	}
+
+	iterate := func(ctx *Context, _, _ *Object, args []*Value) *Value {
+		var data *object_data
+		if len(args) > 0 {
+			data = ctx.nativeObjectData(args[0])
+		}
+		if data == nil {
+			panic("Symbol.iterator called on an object that is not a Go value")
+		}
+		it := newGoIterator(data.val)
+		if it == nil {
+			panic("Go value of type " + data.typ.String() + " is not iterable")
+		}
+		ret, err := ctx.newJSIterator(it)
+		if err != nil {
+			panic(err)
+		}
+		return ret
+	}
+	ret, err := ctx.callHelper(`(function (iterator) {
+		return function (iterate) {
+			if (!iterator) return null;
+			var proto = {};
+			proto[iterator] = function () { return iterate(this); };
+			return proto;
+		};
+	})(typeof Symbol === 'function' ? Symbol.iterator : undefined)`, ctx.NewFunctionWithCallback(iterate).ToValue())
+	if err != nil || !ret.IsObject() {
+		return nil
+	}
+	ret.Protect()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.iterable != nil {
+		ret.Unprotect()
+		return ctx.newObject(s.iterable.ref)
+	}
+	s.iterable = ret.ToObjectOrDie()
+	return ctx.newObject(s.iterable.ref)
+}
@@ -0,0 +1,178 @@
+package gojs
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIterate(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"[1, 'a', null]", "1,a,null"},
+		{"'héllo'", "h,é,l,l,o"},
+		{"new Set([1, 2, 2, 3])", "1,2,3"},
+		{"new Map([['a', 1], ['b', 2]])", "a,1,b,2"},
+		{"(function* () { yield 1; yield 2; })()", "1,2"},
+		{"({length: 2, 0: 'x', 1: 'y'})", "x,y"},
+	}
+	for _, test := range tests {
+		v, err := ctx.EvaluateScript(test.script, nil, "./iterate_test.go", 1)
+		if err != nil {
+			t.Errorf("%s: ctx.EvaluateScript returned an error (%v)", test.script, err)
+			continue
+		}
+		var got []string
+		err = v.Iterate(func(item *Value) error {
+			got = append(got, item.String())
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: v.Iterate returned an error (%v)", test.script, err)
+		}
+		if strings.Join(got, ",") != test.want {
+			t.Errorf("%s: v.Iterate produced %q, want %q", test.script, strings.Join(got, ","), test.want)
+		}
+	}
+
+	if err := ctx.NewNumberValue(1).Iterate(func(*Value) error { return nil }); err == nil {
+		t.Errorf("iterating a number did not fail")
+	}
+}
+
+func TestIterateStop(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	v, err := ctx.EvaluateScript("var closed = false; (function* () { try { yield 1; yield 2; yield 3; } finally { closed = true; } })()", nil, "./iterate_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	n := 0
+	err = v.Iterate(func(*Value) error {
+		n++
+		if n == 2 {
+			return StopIteration
+		}
+		return nil
+	})
+	if err != nil || n != 2 {
+		t.Errorf("v.Iterate with StopIteration returned %v after %d values", err, n)
+	}
+	if closed, _ := ctx.EvaluateScript("closed", nil, "./iterate_test.go", 1); !closed.ToBoolean() {
+		t.Errorf("v.Iterate did not close the iterator")
+	}
+
+	boom := errors.New("boom")
+	v, _ = ctx.EvaluateScript("[1, 2]", nil, "./iterate_test.go", 1)
+	if err := v.Iterate(func(*Value) error { return boom }); err != boom {
+		t.Errorf("v.Iterate returned %v, want the error from fn", err)
+	}
+}
+
+type countdown struct{ N int }
+
+func (c *countdown) Iterator() Iterator { return &countdownIterator{c.N} }
+
+type countdownIterator struct{ n int }
+
+func (it *countdownIterator) Next() (interface{}, bool) {
+	if it.n == 0 {
+		return nil, false
+	}
+	it.n--
+	return it.n + 1, true
+}
+
+type pairs struct{}
+
+func (pairs) Range(yield func(string, float64) bool) {
+	for _, k := range []string{"a", "b", "c"} {
+		if !yield(k, float64(len(k))) {
+			return
+		}
+	}
+}
+
+// endless counts the Range calls that have returned.
+type endless struct{ stopped *atomic.Int32 }
+
+func (e endless) Range(yield func(int) bool) {
+	defer e.stopped.Add(1)
+	for i := 0; yield(i); i++ {
+	}
+}
+
+func TestGoIteratorAbandoned(t *testing.T) {
+	var stopped atomic.Int32
+	ctx := NewContext()
+	ctx.GlobalObject().Set("v", ctx.NewNativeObject(endless{&stopped}).ToValue())
+	_, err := ctx.EvaluateScript("for (var i = 0; i < 10; i++) v[Symbol.iterator]().next()", nil, "./iterate_test.go", 1)
+	if err != nil {
+		t.Fatalf("EvaluateScript returned an error (%v)", err)
+	}
+	ctx.GarbageCollect()
+	ctx.Release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for stopped.Load() < 9 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The collector is conservative, so allow for one iterator still
+	// being referenced from a stale stack slot.
+	if n := stopped.Load(); n < 9 {
+		t.Errorf("%d of 10 abandoned Range iterators were stopped", n)
+	}
+}
+
+func TestGoIterables(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	ch := make(chan string, 2)
+	ch <- "x"
+	ch <- "y"
+	close(ch)
+
+	tests := []struct {
+		value  interface{}
+		script string
+		want   string
+	}{
+		{&countdown{3}, "[...v].join()", "3,2,1"},
+		{[]float64{1.5, 2}, "var s = 0; for (var n of v) s += n; s", "3.5"},
+		{&[]string{"p", "q"}, "Array.from(v).join('')", "pq"},
+		{ch, "[...v].join('')", "xy"},
+		{pairs{}, "var out = []; for (var [k, n] of v) out.push(k + n); out.join()", "a1,b1,c1"},
+		{pairs{}, "var out = []; for (var [k] of v) { out.push(k); break; } out.join()", "a"},
+	}
+	for _, test := range tests {
+		ctx.GlobalObject().Set("v", ctx.NewNativeObject(test.value).ToValue())
+		ret, err := ctx.EvaluateScript(test.script, nil, "./iterate_test.go", 1)
+		if err != nil {
+			t.Errorf("%T: %s returned an error (%v)", test.value, test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%T: %s returned %q, want %q", test.value, test.script, got, test.want)
+		}
+	}
+
+	var got []string
+	ctx.NewNativeObject(&countdown{2}).ToValue().Iterate(func(v *Value) error {
+		got = append(got, v.String())
+		return nil
+	})
+	if strings.Join(got, ",") != "2,1" {
+		t.Errorf("v.Iterate over a Go iterable produced %v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package gojs
+
+// #include <JavaScriptCore/JSObjectRef.h>
+import "C"
+import (
+	"container/list"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Key is an interned property name, converted to a JavaScriptCore string
+// once. Reading the same property of many objects by Key skips converting
+// the name on every access.
+type Key struct {
+	name string
+	str  *String
+}
+
+// String returns the property name.
+func (k *Key) String() string {
+	return k.name
+}
+
+// ref returns the string, which the finalizer releases once k is
+// unreachable; callers keep k alive while they use it.
+func (k *Key) ref() C.JSStringRef {
+	return C.JSStringRef(unsafe.Pointer(k.str))
+}
+
+// Key returns the interned Key for the property name. Every call with the
+// same name on the same context returns the same *Key. Keys may be used with
+// objects of any context, and stay valid for as long as they are referenced,
+// even after the context that interned them is released.
+func (ctx *Context) Key(name string) *Key {
+	s := ctx.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k := s.keys[name]; k != nil {
+		return k
+	}
+	if s.keys == nil {
+		s.keys = make(map[string]*Key)
+	}
+	k := &Key{name, NewString(name)}
+	runtime.SetFinalizer(k, func(k *Key) { k.str.Release() })
+	s.keys[name] = k
+	return k
+}
+
+// HasByKey is Has with an interned name.
+func (obj *Object) HasByKey(k *Key) bool {
+	defer runtime.KeepAlive(k)
+	return bool(C.JSObjectHasProperty(obj.ctx.ref, obj.ref, k.ref()))
+}
+
+// GetByKey is Get with an interned name.
+func (obj *Object) GetByKey(k *Key) (*Value, error) {
+	defer runtime.KeepAlive(k)
+	return obj.get(k.ref())
+}
+
+// SetByKey is Set with an interned name.
+func (obj *Object) SetByKey(k *Key, v *Value) error {
+	defer runtime.KeepAlive(k)
+	return obj.set(k.ref(), v, PropertyAttributeNone)
+}
+
+// DeleteByKey is Delete with an interned name.
+func (obj *Object) DeleteByKey(k *Key) (bool, error) {
+	defer runtime.KeepAlive(k)
+	return obj.delete(k.ref())
+}
+
+//=========================================================
+// Name cache
+//
+
+// nameCache keeps the JavaScriptCore strings of recently used property
+// names, so that Get, Set, Has and Delete do not convert the same names over
+// and over. Strings are immutable and not tied to a context, so one cache
+// serves every context.
+type nameCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List
+}
+
+// A cachedName is released once it has been evicted and its last user is
+// done with it.
+type cachedName struct {
+	name    string
+	str     *String
+	users   int
+	evicted bool
+}
+
+const (
+	maxCachedNames   = 512
+	maxCachedNameLen = 64
+)
+
+var names = nameCache{entries: make(map[string]*list.Element)}
+
+// acquire returns the string for name, which must be passed to release when
+// no longer needed.
+func (c *nameCache) acquire(name string) *cachedName {
+	if len(name) > maxCachedNameLen {
+		return &cachedName{name: name, str: NewString(name), users: 1, evicted: true}
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[name]; ok {
+		c.lru.MoveToFront(e)
+		n := e.Value.(*cachedName)
+		n.users++
+		c.mu.Unlock()
+		return n
+	}
+	c.mu.Unlock()
+
+	n := &cachedName{name: name, str: NewString(name), users: 1}
+	var evicted *cachedName
+	c.mu.Lock()
+	if _, ok := c.entries[name]; ok {
+		// Another goroutine cached it first; keep this one to ourselves.
+		n.evicted = true
+	} else {
+		c.entries[name] = c.lru.PushFront(n)
+		if c.lru.Len() > maxCachedNames {
+			oldest := c.lru.Remove(c.lru.Back()).(*cachedName)
+			delete(c.entries, oldest.name)
+			oldest.evicted = true
+			if oldest.users == 0 {
+				evicted = oldest
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if evicted != nil {
+		evicted.str.Release()
+	}
+	return n
+}
+
+func (c *nameCache) release(n *cachedName) {
+	c.mu.Lock()
+	n.users--
+	done := n.evicted && n.users == 0
+	c.mu.Unlock()
+
+	if done {
+		n.str.Release()
+	}
+}
+
+func (n *cachedName) ref() C.JSStringRef {
+	return C.JSStringRef(unsafe.Pointer(n.str))
+}
@@ -0,0 +1,134 @@
+package gojs
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	k := ctx.Key("answer")
+	if ctx.Key("answer") != k {
+		t.Errorf("ctx.Key did not return the interned key")
+	}
+	if k.String() != "answer" {
+		t.Errorf("k.String() = %q", k.String())
+	}
+
+	obj := ctx.NewEmptyObject()
+	if obj.HasByKey(k) {
+		t.Errorf("obj.HasByKey on an empty object = true")
+	}
+	if err := obj.SetByKey(k, ctx.NewNumberValue(42)); err != nil {
+		t.Fatalf("obj.SetByKey failed: %v", err)
+	}
+	if !obj.HasByKey(k) || !obj.Has("answer") {
+		t.Errorf("obj.HasByKey after SetByKey = false")
+	}
+	v, err := obj.GetByKey(k)
+	if err != nil || v.ToNumberOrDie() != 42 {
+		t.Errorf("obj.GetByKey = %v, %v", v, err)
+	}
+	if ok, err := obj.DeleteByKey(k); !ok || err != nil {
+		t.Errorf("obj.DeleteByKey = %v, %v", ok, err)
+	}
+	if obj.HasByKey(k) {
+		t.Errorf("obj.HasByKey after DeleteByKey = true")
+	}
+
+	// Keys are not tied to the context that interned them.
+	other := NewContext()
+	defer other.Release()
+	o := other.NewEmptyObject()
+	if err := o.SetByKey(k, other.NewStringValue("x")); err != nil || !o.Has("answer") {
+		t.Errorf("o.SetByKey with a key from another context = %v", err)
+	}
+
+	// Nor do they depend on it staying alive.
+	released := NewContext()
+	k2 := released.Key("later")
+	released.Release()
+	if err := o.SetByKey(k2, other.NewStringValue("y")); err != nil || !o.Has("later") {
+		t.Errorf("o.SetByKey with a key from a released context = %v", err)
+	}
+}
+
+func TestNameCache(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	// Hold on to one name while enough others are used to evict it.
+	held := names.acquire("held")
+	obj := ctx.NewEmptyObject()
+	for i := 0; i < 2*maxCachedNames; i++ {
+		name := "p" + strconv.Itoa(i)
+		if err := obj.Set(name, ctx.NewNumberValue(float64(i))); err != nil {
+			t.Fatalf("obj.Set(%q) failed: %v", name, err)
+		}
+	}
+	if err := obj.set(held.ref(), ctx.NewBooleanValue(true), PropertyAttributeNone); err != nil {
+		t.Fatalf("obj.set with an evicted name failed: %v", err)
+	}
+	names.release(held)
+
+	for i := 0; i < 2*maxCachedNames; i++ {
+		name := "p" + strconv.Itoa(i)
+		v, err := obj.Get(name)
+		if err != nil || v.ToNumberOrDie() != float64(i) {
+			t.Errorf("obj.Get(%q) = %v, %v", name, v, err)
+		}
+	}
+	if !obj.Has("held") {
+		t.Errorf("obj.Has(%q) = false", "held")
+	}
+
+	names.mu.Lock()
+	n := names.lru.Len()
+	names.mu.Unlock()
+	if n > maxCachedNames {
+		t.Errorf("name cache holds %d names, want at most %d", n, maxCachedNames)
+	}
+}
+
+func benchmarkObjects(b *testing.B, ctx *Context) []*Object {
+	objs := make([]*Object, 1000)
+	for i := range objs {
+		v, err := ctx.EvaluateScript("({id: 1, name: 'n', value: 2.5})", nil, "", 1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		objs[i] = v.ToObjectOrDie()
+	}
+	return objs
+}
+
+func BenchmarkGetByName(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	objs := benchmarkObjects(b, ctx)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, obj := range objs {
+			obj.Get("id")
+			obj.Get("value")
+		}
+	}
+}
+
+func BenchmarkGetByKey(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+	objs := benchmarkObjects(b, ctx)
+	id, value := ctx.Key("id"), ctx.Key("value")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, obj := range objs {
+			obj.GetByKey(id)
+			obj.GetByKey(value)
+		}
+	}
+}
@@ -8,8 +8,8 @@ import "C"
 import (
 	"errors"
 	"fmt"
-	"log"
 	"reflect"
+	"strconv"
 	"syscall"
 	"unsafe"
 )
@@ -25,6 +25,7 @@ var (
 	nativefunction C.JSClassRef
 	nativeobject   C.JSClassRef
 	nativemethod   C.JSClassRef
+	nativetype     C.JSClassRef
 	objects        map[uintptr]*object_data
 )
 
@@ -57,6 +58,12 @@ func init() {
 		panic(syscall.ENOMEM)
 	}
 
+	// Create the class definition for JavaScriptCore
+	nativetype = C.JSClassDefinition_NativeType()
+	if nativetype == nil {
+		panic(syscall.ENOMEM)
+	}
+
 	// Create map for native objects
 	objects = make(map[uintptr]*object_data)
 }
@@ -94,58 +101,109 @@ func (ctx *Context) newGoValueArray(ptr unsafe.Pointer, size uint) []*Value {
 
 // Given a reflect.Value, this function examines the type and returns a javascript value that best represents the given value. If no acceptable conversion can be found, it panics.
 func (ctx *Context) reflectToJSValue(value reflect.Value) *Value {
+	ret, err := ctx.toJSValue(value)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// toJSValue is reflectToJSValue, returning an error rather than panicking
+// when value cannot be converted.
+func (ctx *Context) toJSValue(value reflect.Value) (*Value, error) {
 	// Allows functions to return JavaScriptCore values and objects
 	// directly.  These we can return without conversion.
 	if value.Type() == reflect.TypeOf((*Value)(nil)) {
 		// Type is already a JavaScriptCore value
-		return value.Interface().(*Value)
+		return value.Interface().(*Value), nil
 	}
 	if value.Type() == reflect.TypeOf((*Object)(nil)) {
 		// Type is already a JavaScriptCore object
 		// nearly there
-		return value.Interface().(*Object).ToValue()
+		return value.Interface().(*Object).ToValue(), nil
 	}
 
 	// Handle simple types directly.  These can be identified by their
 	// types in the package 'reflect'.
 	switch value.Kind() {
-	case (reflect.Int):
-		r := value.Int()
-		return ctx.NewNumberValue(float64(r))
-	case (reflect.Uint):
-		r := value.Uint()
-		return ctx.NewNumberValue(float64(r))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ctx.intToJSValue(value)
 	case (reflect.Float64), (reflect.Float32):
 		r := value.Float()
-		return ctx.NewNumberValue(r)
+		return ctx.NewNumberValue(r), nil
 	case (reflect.String):
 		r := value.String()
-		return ctx.NewStringValue(r)
+		return ctx.NewStringValue(r), nil
+	case reflect.Bool:
+		return ctx.NewBooleanValue(value.Bool()), nil
 	case (reflect.Func):
 		r := value.Interface()
-		return ctx.NewFunctionWithNative(r).ToValue()
+		return ctx.NewFunctionWithNative(r).ToValue(), nil
+	case reflect.Interface:
+		if value.IsNil() {
+			return ctx.NewNullValue(), nil
+		}
+		return ctx.toJSValue(value.Elem())
+	case reflect.Struct:
+		// A copy, as the original may not be addressable.
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		return ctx.NewNativeObject(ptr.Interface()).ToValue(), nil
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return ctx.NewNullValue(), nil
+		}
+		values := make([]*Value, value.Len())
+		for i := range values {
+			v, err := ctx.toJSValue(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		ret, err := ctx.NewArray(values)
+		if err != nil {
+			return nil, err
+		}
+		return ret.ToValue(), nil
+	case reflect.Map:
+		return ctx.mapToJSValue(value)
 	//case (reflect.Struct):
 	//	r := value.Interface()
 	//	return ctx.NewNativeObject(r).ToValue()
 	case (reflect.Ptr):
 		if value.IsNil() {
-			return ctx.NewNullValue()
+			return ctx.NewNullValue(), nil
 		}
 		r := value.Elem()
 		if r.Kind() == reflect.Struct {
 			ret := ctx.NewNativeObject(value.Interface())
-			return ret.ToValue()
+			return ret.ToValue(), nil
 		}
 		if r.Kind() == reflect.Array {
-			panic("Called reflectToJSValue() with a pointer to an array or slice. Most likely, this is a native javascript object you are passing by accident, and meant to pass to newValue() rather than NewValue(). If you really are trying to convert a pointer to an array into a native javascript object, dereference it first (slices are pointers internally anyway, so no significant loss in efficiency).")
+			return nil, errors.New("Called reflectToJSValue() with a pointer to an array or slice. Most likely, this is a native javascript object you are passing by accident, and meant to pass to newValue() rather than NewValue(). If you really are trying to convert a pointer to an array into a native javascript object, dereference it first (slices are pointers internally anyway, so no significant loss in efficiency).")
 			//log.Println("About to make new native object from *[0]uint8")
 			//ret := ctx.NewNativeObject(value.Interface())
 			//log.Println("Made new native object from *[0]uint8")
 			//return ret.ToValue()
 		}
+		return ctx.toJSValue(r)
 	}
 	// No acceptable conversion found.
-	panic("Parameter can not be converted from Go native type. Type is " + value.Kind().String() + ", value is " + value.String())
+	return nil, errors.New("Parameter can not be converted from Go native type. Type is " + value.Kind().String() + ", value is " + value.String())
+}
+
+// panicToException converts a recovered panic to the value thrown to
+// JavaScript. Errors with a JavaScript counterpart, such as *RangeError, are
+// thrown as that error; anything else is thrown as a string.
+func panicToException(ctx *Context, r interface{}) *Value {
+	if err, ok := r.(error); ok {
+		if ret := ctx.errorException(err); ret != nil {
+			return ret
+		}
+	}
+	return panicArgToJSString(ctx, r)
 }
 
 func panicArgToJSString(ctx *Context, r interface{}) *Value {
@@ -161,80 +219,177 @@ func panicArgToJSString(ctx *Context, r interface{}) *Value {
 	return ctx.NewStringValue(msg)
 }
 
-func (ctx *Context) jsValuesToReflect(param []*Value) []reflect.Value {
+// jsValuesToReflect converts the arguments of a call to the native function
+// of type fn. Numeric parameters are converted with toNumeric.
+func (ctx *Context) jsValuesToReflect(param []*Value, fn reflect.Type) []reflect.Value {
 	ret := make([]reflect.Value, len(param))
 
 	for index, item := range param {
+		var paramType reflect.Type
+		switch {
+		case fn.IsVariadic() && index >= fn.NumIn()-1:
+			paramType = fn.In(fn.NumIn() - 1).Elem()
+		case index < fn.NumIn():
+			paramType = fn.In(index)
+		}
+		if paramType != nil {
+			if v, ok := ctx.unwrapNative(item, paramType); ok {
+				ret[index] = v
+				ctx.trace(TraceConversion, "unwrapped argument", "index", index, "type", paramType)
+				continue
+			}
+		}
+		if paramType != nil && isNumericKind(paramType.Kind()) {
+			v, err := ctx.toNumeric(item, paramType, "argument "+strconv.Itoa(index+1))
+			if err != nil {
+				panic(err)
+			}
+			ret[index] = v
+			ctx.trace(TraceConversion, "converted argument", "index", index, "type", paramType)
+			continue
+		}
+
 		var goval interface{}
-		log.Println(index, item)
 
-		switch ctx.ValueType(item) {
+		switch item.Type() {
 		case TypeBoolean:
-			goval = ctx.ToBoolean(item)
+			goval = item.ToBoolean()
 		case TypeNumber:
-			goval = ctx.ToNumberOrDie(item)
+			goval = item.ToNumberOrDie()
 		case TypeString:
-			goval = ctx.ToStringOrDie(item)
+			goval = item.ToStringOrDie()
 		default:
-			panic("Parameter can not be converted to Go native type.")
+			if paramType == nil {
+				panic("Parameter can not be converted to Go native type.")
+			}
+			v, err := ctx.toGoValue(item, paramType, "argument "+strconv.Itoa(index+1))
+			if err != nil {
+				panic(err)
+			}
+			ret[index] = v
+			ctx.trace(TraceConversion, "converted argument", "index", index, "type", paramType)
+			continue
 		}
 
 		ret[index] = reflect.ValueOf(goval)
+		if paramType != nil && ret[index].Kind() == paramType.Kind() {
+			ret[index] = ret[index].Convert(paramType)
+		}
+		ctx.trace(TraceConversion, "converted argument", "index", index, "type", ret[index].Type())
 	}
 
 	return ret
 }
 
-func setNativeFieldFromJSValue(field reflect.Value, ctx *Context, value *Value) (err error) {
+func setNativeFieldFromJSValue(field reflect.Value, name string, ctx *Context, value *Value) (err error) {
+	ctx.trace(TraceConversion, "setting native field", "type", field.Type())
+
 	switch field.Kind() {
 	case reflect.String:
 		var str string
-		str, err = ctx.ToString(value)
+		str, err = value.ToString()
 		if err == nil {
 			field.SetString(str)
 		} else {
 			return
 		}
 
-	case reflect.Float32, reflect.Float64:
-		var flt float64
-		flt, err = ctx.ToNumber(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		var num reflect.Value
+		num, err = ctx.toNumeric(value, field.Type(), "field "+name)
 		if err == nil {
-			field.SetFloat(flt)
-		} else {
-			return
+			field.Set(num)
 		}
 
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		var flt float64
-		flt, err = ctx.ToNumber(value)
-		if err == nil {
-			field.SetInt(int64(flt))
-		} else {
-			return
-		}
+	case reflect.Bool:
+		field.SetBool(value.ToBoolean())
 
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		log.Println("Dealing with uint type of some sort...")
-		var flt float64
-		flt, err = ctx.ToNumber(value)
-		log.Println("Got value!")
-		if err != nil {
-			return
-		}
-		if flt >= 0 {
-			field.SetUint(uint64(flt))
-		} else {
-			err = errors.New("number must be greater than or equal to zero")
-			return
+	case reflect.Interface, reflect.Ptr, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		var v reflect.Value
+		if v, err = ctx.toGoValue(value, field.Type(), "field "+name); err == nil {
+			field.Set(v)
 		}
 
 	default:
-		panic("Parameter can not be converted to Go native type.")
+		err = &TypeError{fmt.Sprintf("gojs: field %s: cannot set a field of type %s", name, field.Type())}
 	}
 	return
 }
 
+// toGoValue converts value to a Go value of type typ, an interface, pointer,
+// struct, slice, array or map type. Native objects holding a Go value of a
+// suitable type give that value; anything else is decoded as by Decode, and
+// null and undefined give the zero value.
+func (ctx *Context) toGoValue(value *Value, typ reflect.Type, what string) (reflect.Value, error) {
+	if v, ok := ctx.unwrapNative(value, typ); ok {
+		return v, nil
+	}
+	if value.IsNull() || value.IsUndefined() {
+		return reflect.Zero(typ), nil
+	}
+	if typ.Kind() == reflect.Interface && typ.NumMethod() > 0 {
+		return reflect.Value{}, &TypeError{fmt.Sprintf("gojs: %s: %s does not implement %s", what, typeName(value), typ)}
+	}
+
+	ptr := reflect.New(typ)
+	if err := value.Decode(ptr.Interface()); err != nil {
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			if decodeErr.Reason != "" {
+				return reflect.Value{}, &RangeError{fmt.Sprintf("gojs: %s: %v", what, err)}
+			}
+			return reflect.Value{}, &TypeError{fmt.Sprintf("gojs: %s: %v", what, err)}
+		}
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+// unwrapNative returns the Go value behind the native object value, or a
+// value it points to, if it is assignable to typ.
+func (ctx *Context) unwrapNative(value *Value, typ reflect.Type) (reflect.Value, bool) {
+	data := ctx.nativeObjectData(value)
+	if data == nil {
+		return reflect.Value{}, false
+	}
+	for v := data.val; ; v = v.Elem() {
+		if v.Type().AssignableTo(typ) {
+			return v, true
+		}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return reflect.Value{}, false
+		}
+	}
+}
+
+// nativeObjectData returns the Go object wrapped by v if v was created by
+// NewNativeObject, or nil otherwise.
+func (ctx *Context) nativeObjectData(v *Value) *object_data {
+	if !bool(C.JSValueIsObjectOfClass(ctx.ref, v.ref, nativeobject)) {
+		return nil
+	}
+	return (*object_data)(C.JSObjectGetPrivate(C.JSObjectRef(unsafe.Pointer(v.ref))))
+}
+
+// nativeFunctionType describes the Go function or method behind v, or
+// returns "" if v is not a native function, callback or method.
+func (ctx *Context) nativeFunctionType(v *Value) string {
+	private := func() *object_data {
+		return (*object_data)(C.JSObjectGetPrivate(C.JSObjectRef(unsafe.Pointer(v.ref))))
+	}
+	switch {
+	case bool(C.JSValueIsObjectOfClass(ctx.ref, v.ref, nativefunction)),
+		bool(C.JSValueIsObjectOfClass(ctx.ref, v.ref, nativecallback)):
+		return private().typ.String()
+	case bool(C.JSValueIsObjectOfClass(ctx.ref, v.ref, nativemethod)):
+		data := private()
+		return "method (" + data.typ.String() + ")." + data.typ.Method(data.method).Name
+	}
+	return ""
+}
+
 //=========================================================
 // Finalizer from JavaScriptCore for all native objects
 //---------------------------------------------------------
@@ -274,13 +429,19 @@ func nativecallback_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContex
 	ctx := NewContextFrom(RawContext(rawCtx))
 	defer func() {
 		if r := recover(); r != nil {
-			*exception = panicArgToJSString(ctx, r).ref
+			*exception = panicToException(ctx, r).ref
 		}
 	}()
+	ctx.checkTerminated()
 
 	data := (*object_data)(data_ptr)
+	ctx.trace(TraceNativeCall, "calling native callback", "arguments", argumentCount)
 	ret := data.val.Interface().(GoFunctionCallback)(
 		ctx, ctx.newObject(function), ctx.newObject(thisObject), ctx.newGoValueArray(arguments, argumentCount) /*(*[1 << 14]*Value)(arguments)[0:argumentCount]*/)
+	ret, err := ctx.adopt("GoFunctionCallback result", ret)
+	if err != nil {
+		panic(err)
+	}
 	if ret == nil {
 		return unsafe.Pointer(nil)
 	}
@@ -308,21 +469,29 @@ func (ctx *Context) NewFunctionWithNative(fn interface{}) *Object {
 	return ctx.newObject(ret)
 }
 
+// argumentCountError describes why argumentCount arguments do not suit a
+// function of type fn, or returns "" if they do. A variadic function takes
+// any number beyond its fixed parameters.
+func argumentCountError(fn reflect.Type, argumentCount uint) string {
+	n := int(argumentCount)
+	switch {
+	case fn.IsVariadic() && n < fn.NumIn()-1:
+		return fmt.Sprintf("called with %d arguments, expected at least %d", n, fn.NumIn()-1)
+	case !fn.IsVariadic() && n != fn.NumIn():
+		return fmt.Sprintf("called with %d arguments, expected %d", n, fn.NumIn())
+	}
+	return ""
+}
+
 func docall(ctx *Context, val reflect.Value, argumentCount uint, arguments unsafe.Pointer) *Value {
 	// Step one, convert the JavaScriptCore array of arguments to
 	// an array of reflect.Values.
 	var in []reflect.Value
 	if argumentCount != 0 {
 		valarr := ctx.newGoValueArray(arguments, argumentCount)
-		log.Println("Converted pointer to go-style array", valarr)
-		log.Println("Converting to relfect.Value s")
-		in = ctx.jsValuesToReflect(valarr)
+		in = ctx.jsValuesToReflect(valarr, val.Type())
 	}
 
-	log.Println("Converted arguments to native go reflect types. About to actually call the callback function...")
-
-	log.Println(in)
-
 	// Step two, perform the call
 	out := val.Call(in)
 
@@ -335,13 +504,14 @@ func docall(ctx *Context, val reflect.Value, argumentCount uint, arguments unsaf
 }
 
 //export nativefunction_CallAsFunction_go
-func nativefunction_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, _ unsafe.Pointer, _ unsafe.Pointer, argumentCount uint, arguments unsafe.Pointer, exception *C.JSValueRef) unsafe.Pointer {
+func nativefunction_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, function unsafe.Pointer, thisObject unsafe.Pointer, argumentCount uint, arguments unsafe.Pointer, exception *C.JSValueRef) unsafe.Pointer {
 	ctx := NewContextFrom(RawContext(rawCtx))
 	defer func() {
 		if r := recover(); r != nil {
-			*exception = panicArgToJSString(ctx, r).ref
+			*exception = panicToException(ctx, r).ref
 		}
 	}()
+	ctx.checkTerminated()
 
 	// recover the object
 	data := (*object_data)(data_ptr)
@@ -349,11 +519,11 @@ func nativefunction_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContex
 	val := data.val
 
 	// Do the number of input parameters match?
-	if typ.NumIn() != int(argumentCount) {
-		panic("Incorrect number of function arguments")
+	if msg := argumentCountError(typ, argumentCount); msg != "" {
+		panic(&TypeError{"gojs: native function " + msg})
 	}
 
-	log.Println("About to docall()!")
+	ctx.trace(TraceNativeCall, "calling native function", "type", typ, "arguments", argumentCount)
 
 	ret := docall(ctx, val, argumentCount, arguments)
 	if ret == nil {
@@ -366,114 +536,384 @@ func nativefunction_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContex
 // Native Object
 //---------------------------------------------------------
 
+// NewNativeObject wraps the Go value obj, usually a pointer to a struct, in
+// a JavaScript object. Scripts read and write its exported fields and call
+// its methods through the object.
+//
+// Fields holding structs, slices, arrays and maps are wrapped in turn rather
+// than copied, so changes made through them reach the Go value: slices and
+// arrays act as array-likes with length and indices, and maps as objects
+// keyed by their keys. Map elements are not addressable in Go, so structs
+// stored directly in maps are copies. Scripts grow a slice by assigning to
+// the index just past its end, or to its length, which may add at most
+// 65536 elements at a time.
+//
+// Methods live on a prototype shared by all native objects of the same type,
+// and take their receiver from this when called.
+//
+// Any Go value may be wrapped. Named types such as type Celsius float64
+// expose their methods, and convert to numbers and strings as their
+// underlying kind does.
 func (ctx *Context) NewNativeObject(obj interface{}) *Object {
-	// The obj must be a pointer to a struct
-	// TODO:  add error checking code
+	if obj == nil {
+		panic("gojs: NewNativeObject called with a nil interface value")
+	}
+	return ctx.newNativeObject(reflect.ValueOf(obj))
+}
+
+// NewNativeObjectAs wraps v as a value of its static type T. When T is an
+// interface type, scripts see the methods of the interface only, whatever
+// the dynamic type of v.
+func NewNativeObjectAs[T any](ctx *Context, v T) *Object {
+	return ctx.newNativeObject(reflect.ValueOf(&v).Elem())
+}
 
+func (ctx *Context) newNativeObject(val reflect.Value) *Object {
 	data := &object_data{
-		reflect.TypeOf(obj),
-		reflect.ValueOf(obj),
+		val.Type(),
+		val,
 		0}
 	register(data)
 
-	ret := C.JSObjectMake(ctx.ref, nativeobject, unsafe.Pointer(data))
-	return ctx.newObject(ret)
+	ret := ctx.newObject(C.JSObjectMake(ctx.ref, nativeobject, unsafe.Pointer(data)))
+	// Without its prototype, the object still exposes the Go value's fields
+	// and elements, only not its methods.
+	if proto, err := ctx.nativePrototype(data.typ); err == nil {
+		ret.SetPrototype(proto.ToValue())
+	}
+	return ret
+}
+
+// liveValue converts v, a field or element of a native object, for
+// JavaScript. Structs, slices, arrays and maps are wrapped by native objects
+// that refer to v itself where it is addressable.
+func (ctx *Context) liveValue(v reflect.Value) *Value {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array:
+		if v.CanAddr() {
+			return ctx.newNativeObject(v.Addr()).ToValue()
+		}
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ctx.newNativeObject(ptr).ToValue()
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return ctx.NewNullValue()
+		}
+		if v.Kind() == reflect.Slice && v.CanAddr() {
+			// Through a pointer, so that appends are seen by the field.
+			return ctx.newNativeObject(v.Addr()).ToValue()
+		}
+		return ctx.newNativeObject(v).ToValue()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ctx.NewNullValue()
+		}
+		switch v.Elem().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+			return ctx.newNativeObject(v).ToValue()
+		}
+		return ctx.liveValue(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return ctx.NewNullValue()
+		}
+		return ctx.liveValue(v.Elem())
+	}
+	return ctx.reflectToJSValue(v)
+}
+
+// elem returns the Go value behind a native object, with pointers followed.
+func (data *object_data) elem() reflect.Value {
+	val := data.val
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	return val
+}
+
+// property returns the field, element or map entry name of the Go value
+// behind a native object, or an invalid Value if there is none. Fields may
+// be promoted from embedded structs; ambiguous names are an error.
+func (data *object_data) property(name string) (reflect.Value, error) {
+	val := data.elem()
+	switch val.Kind() {
+	case reflect.Struct:
+		field, err := structField(val, name)
+		if err == errNoProperty {
+			return reflect.Value{}, nil
+		}
+		return field, err
+	case reflect.Slice, reflect.Array:
+		if i, ok := arrayIndex(name); ok && i < val.Len() {
+			return val.Index(i), nil
+		}
+	case reflect.Map:
+		if key, ok := parseMapKey(name, val.Type().Key()); ok {
+			return val.MapIndex(key), nil
+		}
+	}
+	return reflect.Value{}, nil
+}
+
+// arrayIndex parses name as an array index in canonical form.
+func arrayIndex(name string) (int, bool) {
+	i, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || strconv.FormatUint(i, 10) != name {
+		return 0, false
+	}
+	return int(i), true
 }
 
 //export nativeobject_GetProperty_go
-func nativeobject_GetProperty_go(data_ptr, uctx, _, propertyName unsafe.Pointer, exception *unsafe.Pointer) unsafe.Pointer {
+func nativeobject_GetProperty_go(data_ptr, uctx, _, propertyName unsafe.Pointer, exception *unsafe.Pointer) (ret unsafe.Pointer) {
 	ctx := NewContextFrom(RawContext(uctx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = unsafe.Pointer(panicToException(ctx, r).ref)
+			ret = nil
+		}
+	}()
+	ctx.checkTerminated()
+
 	// Get name of property as a go string
 	name := (*String)(propertyName).String()
 
 	// Reconstruct the object interface
 	data := (*object_data)(data_ptr)
 
-	// Drill down through reflect to find the property
-	val := data.val
-	if ptrvalue := val; ptrvalue.Kind() == reflect.Ptr {
-		val = ptrvalue.Elem()
-	}
-	struct_val := val
-	if struct_val.Kind() != reflect.Struct {
-		return nil
+	// Can we locate a field, element or entry with the proper name?
+	field, err := data.property(name)
+	if err != nil {
+		panic(err)
 	}
-
-	// Can we locate a field with the proper name?
-	field := struct_val.FieldByName(name)
 	if field.IsValid() {
-		return unsafe.Pointer(ctx.reflectToJSValue(field).ref)
+		return unsafe.Pointer(ctx.liveValue(field).ref)
 	}
-
-	// Can we locate a method with the proper name?
-	typ := data.typ
-	for lp := 0; lp < typ.NumMethod(); lp++ {
-		if typ.Method(lp).Name == name {
-			ret := newNativeMethod(ctx, data, lp)
-			return unsafe.Pointer(ret.ref)
-		}
+	if elem := data.elem(); name == "length" && (elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array) {
+		return unsafe.Pointer(ctx.NewNumberValue(float64(elem.Len())).ref)
 	}
 
-	// No matches found
+	// No matches found; methods are found on the prototype.
 	return nil
 }
 
 //export nativeobject_SetProperty_go
-func nativeobject_SetProperty_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, _, propertyName C.JSStringRef, value C.JSValueRef, exception *C.JSValueRef) C.char {
+func nativeobject_SetProperty_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, _, propertyName C.JSStringRef, value C.JSValueRef, exception *C.JSValueRef) (ret C.char) {
 	ctx := NewContextFrom(RawContext(rawCtx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = panicToException(ctx, r).ref
+			ret = 0
+		}
+	}()
+	ctx.checkTerminated()
+
 	// Get name of property as a go string
 	name := newStringFromRef(propertyName).String()
 
 	// Reconstruct the object interface
 	data := (*object_data)(data_ptr)
 
-	// Drill down through reflect to find the property
-	val := data.val
-	if ptrvalue := val; ptrvalue.Kind() == reflect.Ptr {
-		val = ptrvalue.Elem()
+	err := data.setProperty(ctx, name, ctx.newValue(C.JSValueRef(value)))
+	if err == errNoProperty {
+		return 0
 	}
-	struct_val := val
-	if struct_val.Kind() != reflect.Struct {
-		*exception = ctx.newErrorOrPanic("object is not a Go struct")
+	if err != nil {
+		*exception = ctx.exceptionFor(err)
 		return 0
 	}
+	return 1
+}
 
-	field := struct_val.FieldByName(name)
-	if !field.IsValid() {
-		return 0
+// errNoProperty is returned by setProperty for names that do not match a
+// field, element or entry, which are left to JavaScriptCore to store.
+var errNoProperty = errors.New("no such property")
+
+func (data *object_data) setProperty(ctx *Context, name string, value *Value) error {
+	val := data.elem()
+	switch val.Kind() {
+	case reflect.Struct:
+		field, err := structField(val, name)
+		if err != nil {
+			return err
+		}
+		return setNativeFieldFromJSValue(field, name, ctx, value)
+
+	case reflect.Slice, reflect.Array:
+		if name == "length" {
+			return setLength(ctx, val, value)
+		}
+		i, ok := arrayIndex(name)
+		if !ok {
+			return errNoProperty
+		}
+		if i == val.Len() && val.Kind() == reflect.Slice && val.CanSet() {
+			val.Set(reflect.Append(val, reflect.Zero(val.Type().Elem())))
+		}
+		if i >= val.Len() {
+			return &RangeError{fmt.Sprintf("gojs: index %d is out of range for %s of length %d", i, val.Type(), val.Len())}
+		}
+		return setNativeFieldFromJSValue(val.Index(i), "index "+name, ctx, value)
+
+	case reflect.Map:
+		key, ok := parseMapKey(name, val.Type().Key())
+		if !ok {
+			return &TypeError{fmt.Sprintf("gojs: %q is not a valid key for %s", name, val.Type())}
+		}
+		if val.IsNil() {
+			return &TypeError{fmt.Sprintf("gojs: cannot add to nil %s", val.Type())}
+		}
+		elem := reflect.New(val.Type().Elem()).Elem()
+		if err := setNativeFieldFromJSValue(elem, name, ctx, value); err != nil {
+			return err
+		}
+		val.SetMapIndex(key, elem)
+		return nil
 	}
+	// Other Go values have no properties of their own.
+	return errNoProperty
+}
+
+// maxSliceGrowth is the most elements a script may add to a Go slice by
+// assigning to its length, so that a script cannot make the host allocate
+// without bound. Larger lengths throw a RangeError.
+const maxSliceGrowth = 1 << 16
 
-	err := setNativeFieldFromJSValue(field, ctx, ctx.newValue(C.JSValueRef(value)))
+// setLength resizes a slice, as assigning to the length of an array does.
+func setLength(ctx *Context, val reflect.Value, value *Value) error {
+	n, err := ctx.toNumeric(value, reflect.TypeOf(0), "length")
 	if err != nil {
-		*exception = ctx.newErrorOrPanic(err.Error())
-		return 0
+		return err
 	}
-	return 1
+	length := int(n.Int())
+	switch {
+	case length == val.Len():
+		return nil
+	case val.Kind() != reflect.Slice || !val.CanSet():
+		return &TypeError{fmt.Sprintf("gojs: cannot change the length of %s", val.Type())}
+	case length < 0:
+		return &RangeError{fmt.Sprintf("gojs: invalid length %d", length)}
+	case length < val.Len():
+		val.SetLen(length)
+	case length-val.Len() > maxSliceGrowth:
+		return &RangeError{fmt.Sprintf("gojs: cannot grow %s of length %d to %d, by more than %d elements", val.Type(), val.Len(), length, maxSliceGrowth)}
+	default:
+		val.Set(reflect.AppendSlice(val, reflect.MakeSlice(val.Type(), length-val.Len(), length-val.Len())))
+	}
+	return nil
 }
 
-//export nativeobject_ConvertToString_go
-func nativeobject_ConvertToString_go(data_ptr, ctx, obj unsafe.Pointer) unsafe.Pointer {
-	// Reconstruct the object interface
+//export nativeobject_DeleteProperty_go
+func nativeobject_DeleteProperty_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, propertyName C.JSStringRef, exception *C.JSValueRef) (ret C.bool) {
+	ctx := NewContextFrom(RawContext(rawCtx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = panicToException(ctx, r).ref
+			ret = false
+		}
+	}()
+
+	data := (*object_data)(data_ptr)
+	val := data.elem()
+	if val.Kind() != reflect.Map {
+		return false
+	}
+	key, ok := parseMapKey(newStringFromRef(propertyName).String(), val.Type().Key())
+	if !ok {
+		return false
+	}
+	val.SetMapIndex(key, reflect.Value{})
+	return true
+}
+
+//export nativeobject_GetPropertyNames_go
+func nativeobject_GetPropertyNames_go(data_ptr unsafe.Pointer, rawCtx C.JSContextRef, names C.JSPropertyNameAccumulatorRef) {
+	// JavaScriptCore gives this callback no way to throw, so a panic, as
+	// from a map key's String method, leaves the names added so far.
+	defer func() {
+		recover()
+	}()
+
 	data := (*object_data)(data_ptr)
+	for _, name := range data.propertyNames() {
+		str := NewString(name)
+		C.JSPropertyNameAccumulatorAddName(names, C.JSStringRef(unsafe.Pointer(str)))
+		str.Release()
+	}
+}
 
-	// Can we get a string?
-	if stringer, ok := data.val.Interface().(Stringer); ok {
-		str := stringer.String()
-		ret := NewString(str)
-		return unsafe.Pointer(ret)
+// propertyNames lists the fields, indices or keys of the Go value behind a
+// native object.
+func (data *object_data) propertyNames() []string {
+	val := data.elem()
+	var names []string
+	switch val.Kind() {
+	case reflect.Struct:
+		// Skip fields promoted through nil embedded pointers.
+		for _, name := range fieldNames(val.Type()) {
+			if _, err := structField(val, name); err == nil {
+				names = append(names, name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			names = append(names, strconv.Itoa(i))
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(val) {
+			names = append(names, fmt.Sprint(key.Interface()))
+		}
 	}
+	return names
+}
 
-	return nil
+//export nativeobject_ConvertToString_go
+func nativeobject_ConvertToString_go(data_ptr, uctx, obj unsafe.Pointer, exception *C.JSValueRef) (ret unsafe.Pointer) {
+	ctx := NewContextFrom(RawContext(uctx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = panicToException(ctx, r).ref
+			ret = nil
+		}
+	}()
+
+	str := (*object_data)(data_ptr).toString(ctx)
+	return unsafe.Pointer(NewString(str))
+}
+
+//export nativeobject_ConvertToNumber_go
+func nativeobject_ConvertToNumber_go(data_ptr, uctx unsafe.Pointer, number *C.double, exception *C.JSValueRef) (ret C.int) {
+	ctx := NewContextFrom(RawContext(uctx))
+	defer func() {
+		if r := recover(); r != nil {
+			*exception = panicToException(ctx, r).ref
+			ret = 0
+		}
+	}()
+
+	num, ok, err := (*object_data)(data_ptr).toNumber()
+	if err != nil {
+		*exception = ctx.exceptionFor(err)
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	*number = C.double(num)
+	return 1
 }
 
 //=========================================================
 // Native Method
 //---------------------------------------------------------
 
-func newNativeMethod(ctx *Context, obj *object_data, method int) *Object {
+// newNativeMethod creates the function for the method of typ with index
+// method. Its receiver is the native object it is called on.
+func newNativeMethod(ctx *Context, typ reflect.Type, method int) *Object {
 	data := &object_data{
-		obj.typ,
-		obj.val,
+		typ,
+		reflect.Value{},
 		method}
 	register(data)
 
@@ -486,22 +926,39 @@ func nativemethod_CallAsFunction_go(data_ptr unsafe.Pointer, rawCtx C.JSContextR
 	ctx := NewContextFrom(RawContext(rawCtx))
 	defer func() {
 		if r := recover(); r != nil {
-			*exception = panicArgToJSString(ctx, r).ref
+			*exception = panicToException(ctx, r).ref
 		}
 	}()
+	ctx.checkTerminated()
 
 	// Reconstruct the object interface
 	data := (*object_data)(data_ptr)
 
+	// Find the receiver
+	var receiver *object_data
+	if thisObject != nil {
+		receiver = ctx.nativeObjectData(ctx.newValue(C.JSValueRef(thisObject)))
+	}
+	if receiver == nil || receiver.typ != data.typ {
+		panic(&TypeError{fmt.Sprintf("gojs: method (%s).%s called on an incompatible receiver", data.typ, data.typ.Method(data.method).Name)})
+	}
+	if receiver.val.Kind() == reflect.Interface && receiver.val.IsNil() {
+		panic(&TypeError{fmt.Sprintf("gojs: method (%s).%s called on a nil interface value", data.typ, data.typ.Method(data.method).Name)})
+	}
+
 	// Get the method
-	method := data.val.Method(data.method)
+	method := receiver.val.Method(data.method)
+	ctx.trace(TraceNativeCall, "calling native method", "type", data.typ, "method", data.typ.Method(data.method).Name, "arguments", argumentCount)
 
 	// Do the number of input parameters match?
-	if method.Type().NumIn() != int(argumentCount) {
-		panic(fmt.Sprintf("Incorrect number of function arguments! Got %d, expected %d!", method.Type().NumIn(), int(argumentCount)))
+	if msg := argumentCountError(method.Type(), argumentCount); msg != "" {
+		panic(&TypeError{fmt.Sprintf("gojs: method (%s).%s %s", data.typ, data.typ.Method(data.method).Name, msg)})
 	}
 
 	// Perform the call
 	ret := docall(ctx, method, argumentCount, arguments)
+	if ret == nil {
+		return nil
+	}
 	return unsafe.Pointer(ret.ref)
 }
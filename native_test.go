@@ -2,6 +2,9 @@ package gojs
 
 import (
 	"log"
+	"reflect"
+	"strconv"
+	"strings"
 	"syscall"
 	"testing"
 	"unsafe"
@@ -343,6 +346,36 @@ func TestNativeFunction3(t *testing.T) {
 	}
 }
 
+func TestNativeFunctionVariadic(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	fn := ctx.NewFunctionWithNative(func(sep string, parts ...float64) string {
+		strs := make([]string, len(parts))
+		for i, p := range parts {
+			strs[i] = strconv.FormatFloat(p, 'g', -1, 64)
+		}
+		return strings.Join(strs, sep)
+	})
+	if err := ctx.GlobalObject().Set("join", fn.ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for script, want := range map[string]string{
+		"join('-')":         "",
+		"join('-', 1)":      "1",
+		"join('-', 1, 2.5)": "1-2.5",
+	} {
+		ret, err := ctx.EvaluateScript(script, nil, "", 1)
+		if err != nil || ret.String() != want {
+			t.Errorf("%s = %v, %v, want %q", script, ret, err, want)
+		}
+	}
+	name, message := throws(t, ctx, "join()")
+	if name != "TypeError" || !strings.Contains(message, "expected at least 1") {
+		t.Errorf("join() threw %s: %s", name, message)
+	}
+}
+
 func TestNativeFunctionPanic(t *testing.T) {
 	ctx := NewContext()
 	defer ctx.Release()
@@ -575,3 +608,357 @@ func TestNewNativeObjectMethod(t *testing.T) {
 		t.Errorf("ctx.EvaluateScript 'n.Null()'did not return a javascript null value.")
 	}
 }
+
+type method_counter struct{ N int }
+
+func (c *method_counter) Inc() { c.N++ }
+
+func TestNativeMethodVoid(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	c := &method_counter{}
+	if err := ctx.GlobalObject().Set("c", ctx.NewNativeObject(c).ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := ctx.EvaluateScript("c.Inc(); c.Inc()", nil, "", 1); err != nil || c.N != 2 {
+		t.Errorf("c.Inc() twice failed with %v, N = %d", err, c.N)
+	}
+	name, message := throws(t, ctx, "c.Inc(1)")
+	if name != "TypeError" || !strings.Contains(message, "called with 1 arguments, expected 0") {
+		t.Errorf("c.Inc(1) threw %s: %s", name, message)
+	}
+}
+
+type nested_point struct {
+	X, Y int
+}
+
+type nested_object struct {
+	On     bool
+	Origin nested_point
+	Next   *nested_point
+	Points []nested_point
+	Tags   []string
+	Counts map[string]int
+	Any    interface{}
+	Fixed  [2]float64
+}
+
+func TestNativeObjectNested(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := &nested_object{
+		Origin: nested_point{1, 2},
+		Points: []nested_point{{3, 4}},
+		Tags:   []string{"a"},
+		Counts: map[string]int{"x": 1},
+		Any:    "hello",
+	}
+	if err := ctx.GlobalObject().Set("o", ctx.NewNativeObject(obj).ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"o.On", "false"},
+		{"o.On = true; o.On", "true"},
+		{"o.Origin.X", "1"},
+		{"var p = o.Origin; p.Y = 20; o.Origin.Y", "20"},
+		{"o.Next", "null"},
+		{"o.Next = {X: 5, Y: 6}; o.Next.X", "5"},
+		{"o.Next = o.Origin; o.Next.Y", "20"},
+		{"o.Points.length", "1"},
+		{"o.Points[0].X = 30; o.Points[0].X", "30"},
+		{"o.Tags[1] = 'b'; o.Tags.length + ':' + Array.prototype.join.call(o.Tags)", "2:a,b"},
+		{"o.Tags.length = 1; o.Tags.length", "1"},
+		{"o.Counts.x", "1"},
+		{"o.Counts.y = 2; delete o.Counts.x; Object.keys(o.Counts).join()", "y"},
+		{"o.Any", "hello"},
+		{"o.Any = [1, 'two']; o.Any.length", "2"},
+		{"o.Fixed[1] = 2.5; o.Fixed[1]", "2.5"},
+		{"Object.keys(o).join()", "On,Origin,Next,Points,Tags,Counts,Any,Fixed"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+
+	if !obj.On || obj.Origin.Y != 20 || obj.Points[0].X != 30 {
+		t.Errorf("changes to nested values did not reach Go: %+v", obj)
+	}
+	if obj.Next == nil || *obj.Next != (nested_point{1, 20}) {
+		t.Errorf("o.Next = %+v", obj.Next)
+	}
+	if !reflect.DeepEqual(obj.Tags, []string{"a"}) {
+		t.Errorf("o.Tags = %v", obj.Tags)
+	}
+	if !reflect.DeepEqual(obj.Counts, map[string]int{"y": 2}) {
+		t.Errorf("o.Counts = %v", obj.Counts)
+	}
+	if !reflect.DeepEqual(obj.Any, []interface{}{1.0, "two"}) {
+		t.Errorf("o.Any = %#v", obj.Any)
+	}
+	if obj.Fixed[1] != 2.5 {
+		t.Errorf("o.Fixed = %v", obj.Fixed)
+	}
+
+	for _, script := range []string{
+		"o.Fixed[2] = 1",
+		"o.Fixed.length = 3",
+		"o.Tags[5] = 'x'",
+		"o.Tags.length = Math.pow(2, 53) - 1",
+		"o.Tags[4294967294] = 'x'",
+		"o.Counts.z = 'x'",
+		"o.Origin = 5",
+	} {
+		if _, err := ctx.EvaluateScript(script, nil, "", 1); err == nil {
+			t.Errorf("%s did not throw", script)
+		}
+	}
+}
+
+type embedded_base struct {
+	ID   int
+	Name string
+}
+
+func (b embedded_base) Describe() string {
+	return b.Name
+}
+
+type embedded_extra struct {
+	Name  string
+	Extra bool
+}
+
+type embedded_object struct {
+	embedded_base
+	*embedded_extra
+	Title  string
+	Shape  interface{}
+	hidden int
+}
+
+func TestNativeObjectEmbedded(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := &embedded_object{
+		embedded_base: embedded_base{ID: 1, Name: "base"},
+		Shape:         &nested_point{1, 2},
+	}
+	if err := ctx.GlobalObject().Set("o", ctx.NewNativeObject(obj).ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"o.ID", "1"},
+		{"o.ID = 7; o.ID", "7"},
+		{"o.Describe()", "base"},
+		{"o.Shape.Y = 3; o.Shape.Y", "3"},
+		{"o.hidden", "undefined"},
+		{"Object.keys(o).join()", "Title,Shape,ID"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+	if obj.ID != 7 || obj.Shape.(*nested_point).Y != 3 {
+		t.Errorf("changes to promoted fields did not reach Go: %+v", obj)
+	}
+
+	for _, script := range []string{"o.Name", "o.Name = 'x'", "o.Extra", "o.Extra = true"} {
+		_, err := ctx.EvaluateScript(script, nil, "", 1)
+		if err == nil {
+			t.Errorf("%s did not throw", script)
+		}
+	}
+	_, err := ctx.EvaluateScript("o.Name", nil, "", 1)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous selector") {
+		t.Errorf("o.Name error = %v, want an ambiguous selector", err)
+	}
+
+	obj.embedded_extra = &embedded_extra{Extra: true}
+	ret, err := ctx.EvaluateScript("o.Extra", nil, "", 1)
+	if err != nil || ret.String() != "true" {
+		t.Errorf("o.Extra = %v, %v, want true", ret, err)
+	}
+}
+
+type embedded_leaf struct{ X int }
+
+type embedded_left struct{ embedded_leaf }
+
+type embedded_right struct{ embedded_leaf }
+
+// embedded_diamond reaches embedded_leaf along two paths at the same depth.
+type embedded_diamond struct {
+	embedded_left
+	embedded_right
+}
+
+// embedded_renamed declares a method that hides the promoted field Name.
+type embedded_renamed struct {
+	embedded_base
+}
+
+func (r *embedded_renamed) Name() string {
+	return "method"
+}
+
+func TestNativeObjectPromotionDepth(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	if _, ok := reflect.TypeOf(embedded_diamond{}).FieldByName("X"); ok {
+		t.Fatalf("reflect resolves embedded_diamond.X")
+	}
+	global := ctx.GlobalObject()
+	global.Set("d", ctx.NewNativeObject(&embedded_diamond{}).ToValue())
+	global.Set("r", ctx.NewNativeObject(&embedded_renamed{embedded_base{ID: 1, Name: "field"}}).ToValue())
+
+	_, err := ctx.EvaluateScript("d.X", nil, "", 1)
+	if err == nil || !strings.Contains(err.Error(), "embedded_left.embedded_leaf.X or embedded_right.embedded_leaf.X") {
+		t.Errorf("d.X error = %v, want an ambiguous selector", err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"r.Name()", "method"},
+		{"r.Describe()", "field"},
+		{"Object.keys(r).join()", "ID"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestNativeObjectMethodCache(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	global := ctx.GlobalObject()
+	global.Set("a", ctx.NewNativeObject(&reflect_object{-1, 2, 3.0, "four"}).ToValue())
+	global.Set("b", ctx.NewNativeObject(&reflect_object{1, 2, 3.0, "four"}).ToValue())
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"a.Add === a.Add", "true"},
+		{"a.Add === b.Add", "true"},
+		{"Object.getPrototypeOf(a) === Object.getPrototypeOf(b)", "true"},
+		{"b.Add.call(a)", "2"},
+		{"b.Add()", "4"},
+		{"Object.keys(a).indexOf('Add')", "-1"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+
+	for _, script := range []string{"var f = a.Add; f()", "a.Add.call({})"} {
+		if _, err := ctx.EvaluateScript(script, nil, "", 1); err == nil {
+			t.Errorf("%s did not throw", script)
+		}
+	}
+}
+
+type test_celsius float64
+
+func (c test_celsius) Fahrenheit() float64 {
+	return float64(c)*9/5 + 32
+}
+
+type test_names []string
+
+func (n test_names) Joined() string {
+	return strings.Join(n, "+")
+}
+
+type test_counts map[string]int
+
+func (c test_counts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+func TestNativeObjectNonStruct(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	global := ctx.GlobalObject()
+	global.Set("c", ctx.NewNativeObject(test_celsius(100)).ToValue())
+	global.Set("n", ctx.NewNativeObject(&test_names{"a", "b"}).ToValue())
+	global.Set("m", ctx.NewNativeObject(test_counts{"x": 1, "y": 2}).ToValue())
+	global.Set("s", NewNativeObjectAs[Stringer](ctx, &reflect_object{S: "four"}).ToValue())
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"c.Fahrenheit()", "212"},
+		{"c + 1", "101"},
+		{"String(c)", "100"},
+		{"c.extra = 1; c.extra", "1"},
+		{"n.Joined()", "a+b"},
+		{"n[2] = 'c'; n.Joined()", "a+b+c"},
+		{"m.Total()", "3"},
+		{"m.z = 3; m.Total()", "6"},
+		{"s.String()", "four"},
+		{"typeof s.Add", "undefined"},
+		{"typeof s.S", "undefined"},
+	}
+	for _, test := range tests {
+		ret, err := ctx.EvaluateScript(test.script, nil, "", 1)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.script, err)
+			continue
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s = %q, want %q", test.script, got, test.want)
+		}
+	}
+
+	global.Set("nilStringer", NewNativeObjectAs[Stringer](ctx, nil).ToValue())
+	if _, err := ctx.EvaluateScript("nilStringer.String()", nil, "", 1); err == nil {
+		t.Errorf("nilStringer.String() did not throw")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !gojsdebug
+
+package gojs
+
+// #include <JavaScriptCore/JSContextRef.h>
+import "C"
+
+func debugCreated(C.JSContextRef) {}
+
+func debugReleased(C.JSContextRef) {}
+
+func (ctx *Context) checkLive(op string) {}
@@ -0,0 +1,116 @@
+package gojs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// toNumeric converts value to the numeric type typ, for storing in a native
+// object field or passing as a native function argument. what names the
+// field or argument in errors.
+//
+// Only numbers and BigInts are accepted, unless the context's ConvertOptions
+// set LooseNumbers. Values of the wrong type are a *TypeError; values that
+// do not fit typ, including fractions, NaN and infinities for integer types,
+// are a *RangeError.
+func (ctx *Context) toNumeric(value *Value, typ reflect.Type, what string) (reflect.Value, error) {
+	ret := reflect.New(typ).Elem()
+	loose := ctx.convertOptions().LooseNumbers
+	isFloat := typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64
+	isSigned := typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64
+
+	if value.IsBigInt() {
+		if isFloat {
+			return ret, &TypeError{fmt.Sprintf("gojs: %s: cannot convert a BigInt to %s", what, typ)}
+		}
+		x, err := value.ToBigInt()
+		if err != nil {
+			return ret, err
+		}
+		outOfRange := &RangeError{fmt.Sprintf("gojs: %s: %sn is out of range for %s", what, x, typ)}
+		if isSigned {
+			if !x.IsInt64() || ret.OverflowInt(x.Int64()) {
+				return ret, outOfRange
+			}
+			ret.SetInt(x.Int64())
+		} else {
+			if !x.IsUint64() || ret.OverflowUint(x.Uint64()) {
+				return ret, outOfRange
+			}
+			ret.SetUint(x.Uint64())
+		}
+		return ret, nil
+	}
+
+	if !value.IsNumber() && !loose {
+		return ret, &TypeError{fmt.Sprintf("gojs: %s: expected a number, not %s", what, typeName(value))}
+	}
+	flt, err := value.ToNumber()
+	if err != nil {
+		return ret, err
+	}
+	s := strconv.FormatFloat(flt, 'g', -1, 64)
+
+	if isFloat {
+		if !math.IsInf(flt, 0) && !math.IsNaN(flt) && ret.OverflowFloat(flt) {
+			return ret, &RangeError{fmt.Sprintf("gojs: %s: %s is out of range for %s", what, s, typ)}
+		}
+		ret.SetFloat(flt)
+		return ret, nil
+	}
+
+	if math.IsInf(flt, 0) || math.IsNaN(flt) {
+		return ret, &RangeError{fmt.Sprintf("gojs: %s: %s is not a finite number", what, s)}
+	}
+	if flt != math.Trunc(flt) {
+		if !loose {
+			return ret, &RangeError{fmt.Sprintf("gojs: %s: %s is not an integer", what, s)}
+		}
+		flt = math.Trunc(flt)
+	}
+	outOfRange := &RangeError{fmt.Sprintf("gojs: %s: %s is out of range for %s", what, s, typ)}
+	if isSigned {
+		// -2^63 converts exactly; 2^63, the next float64, does not fit.
+		if flt < -(1<<63) || flt >= 1<<63 || ret.OverflowInt(int64(flt)) {
+			return ret, outOfRange
+		}
+		ret.SetInt(int64(flt))
+	} else {
+		if flt < 0 || flt >= 1<<64 || ret.OverflowUint(uint64(flt)) {
+			return ret, outOfRange
+		}
+		ret.SetUint(uint64(flt))
+	}
+	return ret, nil
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// typeName returns the name typeof gives the type of v, with null named
+// separately.
+func typeName(v *Value) string {
+	switch v.Type() {
+	case TypeUndefined:
+		return "undefined"
+	case TypeNull:
+		return "null"
+	case TypeBoolean:
+		return "boolean"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeSymbol:
+		return "symbol"
+	case TypeBigInt:
+		return "bigint"
+	}
+	if v.IsFunction() {
+		return "function"
+	}
+	return "object"
+}
@@ -0,0 +1,108 @@
+package gojs
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// throws evaluates script and returns the name and message of the error it
+// throws, or "" if it does not throw.
+func throws(t *testing.T, ctx *Context, script string) (name, message string) {
+	t.Helper()
+	ret, err := ctx.EvaluateScript("try { "+script+"; '' } catch (e) { e.name + ': ' + e.message }", nil, "", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	str := ret.String()
+	if str == "" {
+		return "", ""
+	}
+	name, message, _ = strings.Cut(str, ": ")
+	return name, message
+}
+
+func TestNumericFields(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := &struct {
+		I int
+		U uint32
+		F float32
+	}{}
+	if err := ctx.GlobalObject().Set("o", ctx.NewNativeObject(obj).ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		script string
+		name   string
+	}{
+		{"o.I = -7", ""},
+		{"o.I = 3.7", "RangeError"},
+		{"o.I = NaN", "RangeError"},
+		{"o.I = Infinity", "RangeError"},
+		{"o.I = '5'", "TypeError"},
+		{"o.I = null", "TypeError"},
+		{"o.U = 4294967295", ""},
+		{"o.U = 4294967296", "RangeError"},
+		{"o.F = 1.5", ""},
+		{"o.F = 1e39", "RangeError"},
+		{"o.F = NaN", ""},
+		{"o.F = 1n", "TypeError"},
+	}
+	bigints := hasGlobalFunction(ctx, "BigInt")
+	for _, test := range tests {
+		if strings.HasSuffix(test.script, "1n") && !bigints {
+			continue
+		}
+		name, message := throws(t, ctx, test.script)
+		if name != test.name {
+			t.Errorf("%s threw %q (%s), want %q", test.script, name, message, test.name)
+		}
+		field := "field " + test.script[2:3]
+		if name != "" && !strings.Contains(message, field) {
+			t.Errorf("%s threw %q, which does not name %s", test.script, message, field)
+		}
+	}
+	if obj.I != -7 || obj.U != 4294967295 || !math.IsNaN(float64(obj.F)) {
+		t.Errorf("fields = %+v", *obj)
+	}
+
+	ctx.SetConvertOptions(ConvertOptions{LooseNumbers: true})
+	if name, message := throws(t, ctx, "o.I = '3.7'"); name != "" || obj.I != 3 {
+		t.Errorf("loose o.I = '3.7' threw %s: %s, set %d", name, message, obj.I)
+	}
+	if name, _ := throws(t, ctx, "o.I = 'x'"); name != "RangeError" {
+		t.Errorf("loose o.I = 'x' threw %q, want RangeError", name)
+	}
+}
+
+func TestNumericArguments(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	fn := ctx.NewFunctionWithNative(func(a int8, b float64) float64 { return float64(a) * b })
+	if err := ctx.GlobalObject().Set("f", fn.ToValue()); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	ret, err := ctx.EvaluateScript("f(-3, 0.5)", nil, "", 1)
+	if err != nil || ret.ToNumberOrDie() != -1.5 {
+		t.Errorf("f(-3, 0.5) = %v, %v", ret, err)
+	}
+	for script, want := range map[string]string{
+		"f(200, 1)": "RangeError",
+		"f(1.5, 1)": "RangeError",
+		"f(1, '2')": "TypeError",
+	} {
+		name, message := throws(t, ctx, script)
+		if name != want {
+			t.Errorf("%s threw %q (%s), want %q", script, name, message, want)
+		}
+		if !strings.Contains(message, "argument ") {
+			t.Errorf("%s threw %q, which does not name the argument", script, message)
+		}
+	}
+}
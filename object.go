@@ -4,9 +4,13 @@ package gojs
 // #include <JavaScriptCore/JSStringRef.h>
 // #include <JavaScriptCore/JSObjectRef.h>
 // #include "callback.h"
+// #include "bulk.h"
 import "C"
-import "unsafe"
-import "log"
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
 
 type Object struct {
 	ref C.JSObjectRef
@@ -37,7 +41,7 @@ func (ctx *Context) NewEmptyObject() *Object {
 func (ctx *Context) NewObjectWithProperties(properties map[string]*Value) (*Object, error) {
 	obj := ctx.NewEmptyObject()
 	for name, val := range properties {
-		err := ctx.SetProperty(obj, name, val, 0)
+		err := obj.Set(name, val)
 		if err != nil {
 			return nil, err
 		}
@@ -46,6 +50,11 @@ func (ctx *Context) NewObjectWithProperties(properties map[string]*Value) (*Obje
 }
 
 func (ctx *Context) NewArray(items []*Value) (*Object, error) {
+	items, err := ctx.adoptAll("NewArray", items)
+	if err != nil {
+		return nil, err
+	}
+
 	errVal := ctx.newErrorValue()
 
 	ret := ctx.NewEmptyObject()
@@ -116,10 +125,16 @@ func (ctx *Context) NewRegExp(regex string) (*Object, error) {
 }
 
 func (ctx *Context) NewRegExpFromValues(parameters []*Value) (*Object, error) {
+	parameters, err := ctx.adoptAll("NewRegExpFromValues", parameters)
+	if err != nil {
+		return nil, err
+	}
+
 	errVal := ctx.newErrorValue()
+	cParameters, n := ctx.newCValueArray(parameters)
 
 	ret := C.JSObjectMakeRegExp(ctx.ref,
-		C.size_t(len(parameters)), &parameters[0].ref,
+		n, cParameters,
 		&errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
@@ -159,55 +174,89 @@ func (ctx *Context) NewFunction(name string, parameters []string, body string, s
 	return ctx.newObject(ret), nil
 }
 
-func (ctx *Context) GetPrototype(obj *Object) *Value {
-	ret := C.JSObjectGetPrototype(ctx.ref, obj.ref)
-	return ctx.newValue(ret)
+// Context returns the context that obj belongs to.
+func (obj *Object) Context() *Context {
+	return obj.ctx
 }
 
-func (ctx *Context) SetPrototype(obj *Object, rhs *Value) {
-	C.JSObjectSetPrototype(ctx.ref, obj.ref, rhs.ref)
+// Prototype returns the prototype of obj, which is null for objects that
+// have none.
+func (obj *Object) Prototype() *Value {
+	ret := C.JSObjectGetPrototype(obj.ctx.ref, obj.ref)
+	return obj.ctx.newValue(ret)
 }
 
-func (ctx *Context) HasProperty(obj *Object, name string) bool {
-	jsstr := NewString(name)
-	defer jsstr.Release()
+// SetPrototype sets the prototype of obj. It panics with a
+// *ContextMismatchError if proto belongs to another context group.
+func (obj *Object) SetPrototype(proto *Value) {
+	proto, err := obj.ctx.adopt("Object.SetPrototype", proto)
+	if err != nil {
+		panic(err)
+	}
+	C.JSObjectSetPrototype(obj.ctx.ref, obj.ref, proto.ref)
+}
+
+// Has reports whether obj or its prototype chain has the property name.
+func (obj *Object) Has(name string) bool {
+	n := names.acquire(name)
+	defer names.release(n)
 
-	ret := C.JSObjectHasProperty(ctx.ref, obj.ref, C.JSStringRef(unsafe.Pointer(jsstr)))
+	ret := C.JSObjectHasProperty(obj.ctx.ref, obj.ref, n.ref())
 	return bool(ret)
 }
 
-func (ctx *Context) GetProperty(obj *Object, name string) (*Value, error) {
-	jsstr := NewString(name)
-	defer jsstr.Release()
+// Get returns the property name of obj. Missing properties are undefined.
+// The error is the exception thrown by a getter, if any.
+func (obj *Object) Get(name string) (*Value, error) {
+	n := names.acquire(name)
+	defer names.release(n)
+	return obj.get(n.ref())
+}
 
-	errVal := ctx.newErrorValue()
+func (obj *Object) get(name C.JSStringRef) (*Value, error) {
+	errVal := obj.ctx.newErrorValue()
 
-	ret := C.JSObjectGetProperty(ctx.ref, obj.ref, C.JSStringRef(unsafe.Pointer(jsstr)), &errVal.ref)
+	ret := C.JSObjectGetProperty(obj.ctx.ref, obj.ref, name, &errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
 	}
 
-	return ctx.newValue(ret), nil
+	return obj.ctx.newValue(ret), nil
 }
 
-func (ctx *Context) GetPropertyAtIndex(obj *Object, index uint16) (*Value, error) {
-	errVal := ctx.newErrorValue()
+func (obj *Object) GetIndex(index uint32) (*Value, error) {
+	errVal := obj.ctx.newErrorValue()
 
-	ret := C.JSObjectGetPropertyAtIndex(ctx.ref, obj.ref, C.unsigned(index), &errVal.ref)
+	ret := C.JSObjectGetPropertyAtIndex(obj.ctx.ref, obj.ref, C.unsigned(index), &errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
 	}
 
-	return ctx.newValue(ret), nil
+	return obj.ctx.newValue(ret), nil
 }
 
-func (ctx *Context) SetProperty(obj *Object, name string, rhs *Value, attributes uint8) error {
-	jsstr := NewString(name)
-	defer jsstr.Release()
+// Set assigns the property name of obj, as obj[name] = v would.
+func (obj *Object) Set(name string, v *Value) error {
+	return obj.SetWithAttributes(name, v, PropertyAttributeNone)
+}
 
-	errVal := ctx.newErrorValue()
+// SetWithAttributes sets the property name of obj, giving it attributes if
+// it is created. Attributes are ignored for existing properties.
+func (obj *Object) SetWithAttributes(name string, v *Value, attributes uint8) error {
+	n := names.acquire(name)
+	defer names.release(n)
+	return obj.set(n.ref(), v, attributes)
+}
+
+func (obj *Object) set(name C.JSStringRef, v *Value, attributes uint8) error {
+	v, err := obj.ctx.adopt("Object.Set", v)
+	if err != nil {
+		return err
+	}
+
+	errVal := obj.ctx.newErrorValue()
 
-	C.JSObjectSetProperty(ctx.ref, obj.ref, C.JSStringRef(unsafe.Pointer(jsstr)), rhs.ref,
+	C.JSObjectSetProperty(obj.ctx.ref, obj.ref, name, v.ref,
 		(C.JSPropertyAttributes)(attributes), &errVal.ref)
 	if errVal.ref != nil {
 		return errVal
@@ -216,10 +265,15 @@ func (ctx *Context) SetProperty(obj *Object, name string, rhs *Value, attributes
 	return nil
 }
 
-func (ctx *Context) SetPropertyAtIndex(obj *Object, index uint16, rhs *Value) error {
-	errVal := ctx.newErrorValue()
+func (obj *Object) SetIndex(index uint32, v *Value) error {
+	v, err := obj.ctx.adopt("Object.SetIndex", v)
+	if err != nil {
+		return err
+	}
+
+	errVal := obj.ctx.newErrorValue()
 
-	C.JSObjectSetPropertyAtIndex(ctx.ref, obj.ref, C.unsigned(index), rhs.ref, &errVal.ref)
+	C.JSObjectSetPropertyAtIndex(obj.ctx.ref, obj.ref, C.unsigned(index), v.ref, &errVal.ref)
 	if errVal.ref != nil {
 		return errVal
 	}
@@ -227,13 +281,18 @@ func (ctx *Context) SetPropertyAtIndex(obj *Object, index uint16, rhs *Value) er
 	return nil
 }
 
-func (ctx *Context) DeleteProperty(obj *Object, name string) (bool, error) {
-	jsstr := NewString(name)
-	defer jsstr.Release()
+// Delete removes the property name from obj. It returns false if the
+// property could not be deleted, as for PropertyAttributeDontDelete.
+func (obj *Object) Delete(name string) (bool, error) {
+	n := names.acquire(name)
+	defer names.release(n)
+	return obj.delete(n.ref())
+}
 
-	errVal := ctx.newErrorValue()
+func (obj *Object) delete(name C.JSStringRef) (bool, error) {
+	errVal := obj.ctx.newErrorValue()
 
-	ret := C.JSObjectDeleteProperty(ctx.ref, obj.ref, C.JSStringRef(unsafe.Pointer(jsstr)), &errVal.ref)
+	ret := C.JSObjectDeleteProperty(obj.ctx.ref, obj.ref, name, &errVal.ref)
 	if errVal.ref != nil {
 		return false, errVal
 	}
@@ -241,6 +300,110 @@ func (ctx *Context) DeleteProperty(obj *Object, name string) (bool, error) {
 	return bool(ret), nil
 }
 
+// Keys returns the names of the enumerable properties of obj and its
+// prototype chain, in the order a for...in loop visits them. The names are
+// copied out in a single call into JavaScriptCore.
+func (obj *Object) Keys() []string {
+	var count C.size_t
+	var lengths *C.size_t
+	buf := C.gojs_property_names(obj.ctx.ref, obj.ref, &count, &lengths)
+	if buf == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(buf))
+	defer C.free(unsafe.Pointer(lengths))
+
+	keys := make([]string, int(count))
+	lens := unsafe.Slice(lengths, int(count))
+	p := unsafe.Pointer(buf)
+	for i := range keys {
+		keys[i] = C.GoStringN((*C.char)(p), C.int(lens[i]))
+		p = unsafe.Add(p, lens[i]+1)
+	}
+	return keys
+}
+
+// valueSliceBatch is the number of elements ToValueSlice reads per call into
+// JavaScriptCore. The length comes from the script, so the slice grows as
+// elements are read rather than being allocated up front.
+const valueSliceBatch = 256
+
+// ToValueSlice returns the elements of the array-like obj, from index 0 to
+// its length. The elements are read in batches of calls into JavaScriptCore.
+func (obj *Object) ToValueSlice() ([]*Value, error) {
+	length, err := obj.Get("length")
+	if err != nil {
+		return nil, err
+	}
+	n, err := length.ToNumber()
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 || math.IsNaN(n) {
+		return []*Value{}, nil
+	}
+	if n > math.MaxUint32 {
+		return nil, fmt.Errorf("gojs: array length %v is out of range", n)
+	}
+
+	count := uint32(n)
+	values := make([]*Value, 0, min(count, valueSliceBatch))
+	var refs [valueSliceBatch]C.JSValueRef
+	for start := uint32(0); start < count; {
+		batch := min(count-start, valueSliceBatch)
+		errVal := obj.ctx.newErrorValue()
+		C.gojs_array_values(obj.ctx.ref, obj.ref, C.unsigned(start), &refs[0], C.unsigned(batch), &errVal.ref)
+		if errVal.ref != nil {
+			return nil, errVal
+		}
+		for _, ref := range refs[:batch] {
+			values = append(values, obj.ctx.newValue(ref))
+		}
+		start += batch
+	}
+	return values, nil
+}
+
+// Deprecated: use obj.Prototype.
+func (ctx *Context) GetPrototype(obj *Object) *Value {
+	return obj.Prototype()
+}
+
+// Deprecated: use obj.SetPrototype.
+func (ctx *Context) SetPrototype(obj *Object, rhs *Value) {
+	obj.SetPrototype(rhs)
+}
+
+// Deprecated: use obj.Has.
+func (ctx *Context) HasProperty(obj *Object, name string) bool {
+	return obj.Has(name)
+}
+
+// Deprecated: use obj.Get.
+func (ctx *Context) GetProperty(obj *Object, name string) (*Value, error) {
+	return obj.Get(name)
+}
+
+// Deprecated: use obj.GetIndex.
+func (ctx *Context) GetPropertyAtIndex(obj *Object, index uint32) (*Value, error) {
+	return obj.GetIndex(index)
+}
+
+// Deprecated: use obj.Set or obj.SetWithAttributes.
+func (ctx *Context) SetProperty(obj *Object, name string, rhs *Value, attributes uint8) error {
+	return obj.SetWithAttributes(name, rhs, attributes)
+}
+
+// Deprecated: use obj.SetIndex.
+func (ctx *Context) SetPropertyAtIndex(obj *Object, index uint32, rhs *Value) error {
+	return obj.SetIndex(index, rhs)
+}
+
+// Deprecated: use obj.Delete.
+func (ctx *Context) DeleteProperty(obj *Object, name string) (bool, error) {
+	return obj.Delete(name)
+}
+
 // Should NOT be public. WTF.
 func (obj *Object) GetPrivate() unsafe.Pointer {
 	ret := C.JSObjectGetPrivate(obj.ref)
@@ -264,50 +427,80 @@ func (obj *Object) ToValue() *Value {
 	return obj.ctx.newValue(C.JSValueRef(obj.ref))
 }
 
-func (ctx *Context) IsFunction(obj *Object) bool {
-	ret := C.JSObjectIsFunction(ctx.ref, obj.ref)
-	return bool(ret)
+func (obj *Object) IsFunction() bool {
+	return bool(C.JSObjectIsFunction(obj.ctx.ref, obj.ref))
 }
 
-func (ctx *Context) CallAsFunction(obj *Object, thisObject *Object, parameters []*Value) (*Value, error) {
-	errVal := ctx.newErrorValue()
-	cParameters, n := ctx.newCValueArray(parameters)
-	if thisObject == nil {
-		thisObject = ctx.newObject(nil)
-		log.Println(thisObject.ref)
+// Call calls obj as a function with this set to thisObject, or to the
+// global object if thisObject is nil.
+func (obj *Object) Call(thisObject *Object, args ...*Value) (*Value, error) {
+	if err := obj.ctx.adoptObject("Object.Call", thisObject); err != nil {
+		return nil, err
+	}
+	args, err := obj.ctx.adoptAll("Object.Call", args)
+	if err != nil {
+		return nil, err
 	}
 
-	ret := C.JSObjectCallAsFunction(ctx.ref, obj.ref, thisObject.ref, n, cParameters, &errVal.ref)
+	errVal := obj.ctx.newErrorValue()
+	cArgs, n := obj.ctx.newCValueArray(args)
+	var thisRef C.JSObjectRef
+	if thisObject != nil {
+		thisRef = thisObject.ref
+	}
 
+	ret := C.JSObjectCallAsFunction(obj.ctx.ref, obj.ref, thisRef, n, cArgs, &errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
 	}
 
-	return ctx.newValue(ret), nil
+	return obj.ctx.newValue(ret), nil
 }
 
-func (ctx *Context) IsConstructor(obj *Object) bool {
-	ret := C.JSObjectIsConstructor(ctx.ref, obj.ref)
-	return bool(ret)
+func (obj *Object) IsConstructor() bool {
+	return bool(C.JSObjectIsConstructor(obj.ctx.ref, obj.ref))
 }
 
-func (ctx *Context) CallAsConstructor(obj *Object, parameters []*Value) (*Value, error) {
-	errVal := ctx.newErrorValue()
-
-	var Cparameters *C.JSValueRef
-	if len(parameters) > 0 {
-		Cparameters = (*C.JSValueRef)(unsafe.Pointer(&parameters[0]))
+// Construct calls obj as a constructor, as new obj(args...) would.
+func (obj *Object) Construct(args ...*Value) (*Object, error) {
+	args, err := obj.ctx.adoptAll("Object.Construct", args)
+	if err != nil {
+		return nil, err
 	}
 
-	ret := C.JSObjectCallAsConstructor(ctx.ref, obj.ref,
-		C.size_t(len(parameters)),
-		Cparameters,
-		&errVal.ref)
+	errVal := obj.ctx.newErrorValue()
+	cArgs, n := obj.ctx.newCValueArray(args)
+
+	ret := C.JSObjectCallAsConstructor(obj.ctx.ref, obj.ref, n, cArgs, &errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
 	}
 
-	return ctx.newObject(ret).ToValue(), nil
+	return obj.ctx.newObject(ret), nil
+}
+
+// Deprecated: use obj.IsFunction.
+func (ctx *Context) IsFunction(obj *Object) bool {
+	return obj.IsFunction()
+}
+
+// Deprecated: use obj.Call.
+func (ctx *Context) CallAsFunction(obj *Object, thisObject *Object, parameters []*Value) (*Value, error) {
+	return obj.Call(thisObject, parameters...)
+}
+
+// Deprecated: use obj.IsConstructor.
+func (ctx *Context) IsConstructor(obj *Object) bool {
+	return obj.IsConstructor()
+}
+
+// Deprecated: use obj.Construct.
+func (ctx *Context) CallAsConstructor(obj *Object, parameters []*Value) (*Value, error) {
+	ret, err := obj.Construct(parameters...)
+	if err != nil {
+		return nil, err
+	}
+	return ret.ToValue(), nil
 }
 
 //=========================================================
@@ -342,12 +535,12 @@ func (ref *PropertyNameArray) Release() {
 	C.JSPropertyNameArrayRelease(C.JSPropertyNameArrayRef(unsafe.Pointer(ref)))
 }
 
-func (ref *PropertyNameArray) Count() uint16 {
+func (ref *PropertyNameArray) Count() int {
 	ret := C.JSPropertyNameArrayGetCount(C.JSPropertyNameArrayRef(unsafe.Pointer(ref)))
-	return uint16(ret)
+	return int(ret)
 }
 
-func (ref *PropertyNameArray) NameAtIndex(index uint16) string {
+func (ref *PropertyNameArray) NameAtIndex(index int) string {
 	jsstr := C.JSPropertyNameArrayGetNameAtIndex(C.JSPropertyNameArrayRef(unsafe.Pointer(ref)), C.size_t(index))
 	defer C.JSStringRelease(jsstr)
 	return (*String)(unsafe.Pointer(jsstr)).String()
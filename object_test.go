@@ -220,3 +220,174 @@ func TestNewCallAsFunction(t *testing.T) {
 		t.Errorf("ctx.CallAsFunction did not compute the right value")
 	}
 }
+
+func TestObjectMethods(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := ctx.NewEmptyObject()
+	if err := obj.Set("a", ctx.NewNumberValue(1)); err != nil {
+		t.Fatalf("obj.Set returned an error (%v)", err)
+	}
+	if err := obj.SetWithAttributes("b", ctx.NewStringValue("x"), PropertyAttributeDontEnum|PropertyAttributeDontDelete); err != nil {
+		t.Fatalf("obj.SetWithAttributes returned an error (%v)", err)
+	}
+	if !obj.Has("a") || !obj.Has("b") || obj.Has("c") {
+		t.Errorf("obj.Has does not match the properties that were set")
+	}
+	if v, err := obj.Get("a"); err != nil || v.ToNumberOrDie() != 1 {
+		t.Errorf("obj.Get(\"a\") returned %v, %v", v, err)
+	}
+	if v, err := obj.Get("c"); err != nil || !v.IsUndefined() {
+		t.Errorf("obj.Get of a missing property returned %v, %v", v, err)
+	}
+	if keys := obj.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("obj.Keys returned %v, want [a]", keys)
+	}
+	if ok, err := obj.Delete("b"); ok || err != nil {
+		t.Errorf("obj.Delete deleted a DontDelete property (%v, %v)", ok, err)
+	}
+	if ok, err := obj.Delete("a"); !ok || err != nil || obj.Has("a") {
+		t.Errorf("obj.Delete failed (%v, %v)", ok, err)
+	}
+	if proto := obj.Prototype(); !proto.IsObject() {
+		t.Errorf("obj.Prototype returned %v", proto)
+	}
+	obj.SetPrototype(ctx.NewNullValue())
+	if proto := obj.Prototype(); !proto.IsNull() {
+		t.Errorf("obj.Prototype after SetPrototype(null) returned %v", proto)
+	}
+
+	arr, err := ctx.NewArray(nil)
+	if err != nil {
+		t.Fatalf("ctx.NewArray returned an error (%v)", err)
+	}
+	if err := arr.SetIndex(2, ctx.NewBooleanValue(true)); err != nil {
+		t.Fatalf("arr.SetIndex returned an error (%v)", err)
+	}
+	if v, err := arr.GetIndex(2); err != nil || !v.ToBoolean() {
+		t.Errorf("arr.GetIndex(2) returned %v, %v", v, err)
+	}
+	if v, _ := arr.Get("length"); v.ToNumberOrDie() != 3 {
+		t.Errorf("array length is %v after SetIndex(2)", v)
+	}
+}
+
+func TestObjectCallAndConstruct(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	ret, err := ctx.EvaluateScript("(function Pair(a, b) { this.sum = a + b; return a * b; })", nil, "./object_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	fn := ret.ToObjectOrDie()
+	if !fn.IsFunction() || !fn.IsConstructor() || !ret.IsFunction() {
+		t.Errorf("function is not reported as a function and constructor")
+	}
+
+	this := ctx.NewEmptyObject()
+	v, err := fn.Call(this, ctx.NewNumberValue(2), ctx.NewNumberValue(3))
+	if err != nil {
+		t.Fatalf("fn.Call returned an error (%v)", err)
+	}
+	if v.ToNumberOrDie() != 6 {
+		t.Errorf("fn.Call returned %v, want 6", v)
+	}
+	if sum, _ := this.Get("sum"); sum.ToNumberOrDie() != 5 {
+		t.Errorf("fn.Call did not pass this (sum is %v)", sum)
+	}
+
+	obj, err := fn.Construct(ctx.NewNumberValue(4), ctx.NewNumberValue(5))
+	if err != nil {
+		t.Fatalf("fn.Construct returned an error (%v)", err)
+	}
+	if sum, _ := obj.Get("sum"); sum.ToNumberOrDie() != 9 {
+		t.Errorf("fn.Construct did not pass its arguments (sum is %v)", sum)
+	}
+
+	// The deprecated form goes through the same code.
+	cv, err := ctx.CallAsConstructor(fn, []*Value{ctx.NewNumberValue(1), ctx.NewNumberValue(1)})
+	if err != nil {
+		t.Fatalf("ctx.CallAsConstructor returned an error (%v)", err)
+	}
+	if sum, _ := cv.ToObjectOrDie().Get("sum"); sum.ToNumberOrDie() != 2 {
+		t.Errorf("ctx.CallAsConstructor did not pass its arguments (sum is %v)", sum)
+	}
+}
+
+func TestLargeArrays(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	ret, err := ctx.EvaluateScript("var a = []; for (var i = 0; i < 70000; i++) a.push(i); a", nil, "./object_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	arr := ret.ToObjectOrDie()
+	if v, err := arr.GetIndex(69999); err != nil || v.ToNumberOrDie() != 69999 {
+		t.Errorf("arr.GetIndex past 65535 returned %v, %v", v, err)
+	}
+	if err := arr.SetIndex(70000, ctx.NewNumberValue(-1)); err != nil {
+		t.Fatalf("arr.SetIndex returned an error (%v)", err)
+	}
+
+	values, err := arr.ToValueSlice()
+	if err != nil {
+		t.Fatalf("arr.ToValueSlice returned an error (%v)", err)
+	}
+	if len(values) != 70001 {
+		t.Fatalf("arr.ToValueSlice returned %d values, want 70001", len(values))
+	}
+	if values[65536].ToNumberOrDie() != 65536 || values[70000].ToNumberOrDie() != -1 {
+		t.Errorf("arr.ToValueSlice returned wrong values")
+	}
+
+	names := ctx.CopyPropertyNames(arr)
+	defer names.Release()
+	if n := names.Count(); n != 70001 {
+		t.Errorf("names.Count returned %d, want 70001", n)
+	}
+	if name := names.NameAtIndex(66000); name != "66000" {
+		t.Errorf("names.NameAtIndex(66000) returned %q", name)
+	}
+	if keys := arr.Keys(); len(keys) != 70001 || keys[70000] != "70000" {
+		t.Errorf("arr.Keys returned %d keys", len(keys))
+	}
+}
+
+func TestKeysAndValueSlice(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	ret, err := ctx.EvaluateScript("({a: 1, 'é': 2, 'x\\u0000y': 3})", nil, "./object_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	keys := ret.ToObjectOrDie().Keys()
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "é" || keys[2] != "x\x00y" {
+		t.Errorf("obj.Keys returned %q", keys)
+	}
+
+	values, err := ret.ToObjectOrDie().ToValueSlice()
+	if err != nil || len(values) != 0 {
+		t.Errorf("ToValueSlice of an object without length returned %v, %v", values, err)
+	}
+
+	ret, err = ctx.EvaluateScript("({length: 2, get 1() { throw new Error('boom'); }})", nil, "./object_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if _, err := ret.ToObjectOrDie().ToValueSlice(); err == nil {
+		t.Errorf("ToValueSlice did not return the exception thrown by a getter")
+	}
+
+	// A huge length is not allocated up front; the getter stops the read.
+	ret, err = ctx.EvaluateScript("({length: 4294967295, get 1000() { throw new Error('boom'); }})", nil, "./object_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if _, err := ret.ToObjectOrDie().ToValueSlice(); err == nil {
+		t.Errorf("ToValueSlice did not return the exception thrown by a getter past the first batch")
+	}
+}
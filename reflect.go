@@ -2,12 +2,25 @@ package gojs
 
 import "reflect"
 
-// NewValue returns a JavaScript value corresponding to a Go value.
+// NewValue returns a JavaScript value corresponding to a Go value. It panics
+// if the value cannot be converted, as for an int64 beyond
+// Number.MAX_SAFE_INTEGER under Int64AsNumber; ConvertValue returns the
+// error instead.
 func (ctx *Context) NewValue(goValue interface{}) *Value {
+	ret, err := ctx.ConvertValue(goValue)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// ConvertValue returns a JavaScript value corresponding to a Go value, or an
+// error, such as a *RangeError, if the value cannot be converted.
+func (ctx *Context) ConvertValue(goValue interface{}) (*Value, error) {
 	// Handle simple case right off
 	if goValue == nil {
-		return ctx.NewNullValue()
+		return ctx.NewNullValue(), nil
 	}
 
-	return ctx.reflectToJSValue(reflect.ValueOf(goValue))
+	return ctx.toJSValue(reflect.ValueOf(goValue))
 }
@@ -1,10 +1,12 @@
 package gojs
 
-// #include <stdlib.h>
 // #include <JavaScriptCore/JSStringRef.h>
 import "C"
 import (
-	"syscall"
+	"slices"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -15,10 +17,25 @@ import (
 type String struct {
 }
 
+// NewString creates a string from UTF-8. Invalid UTF-8 becomes U+FFFD, and
+// NULs are kept.
 func NewString(value string) *String {
-	cvalue := C.CString(value)
-	defer C.free(unsafe.Pointer(cvalue))
-	ref := C.JSStringCreateWithUTF8CString(cvalue)
+	buf := utf16Scratch.Get().(*[]uint16)
+	chars := appendUTF16((*buf)[:0], value)
+	ret := NewStringFromUTF16(chars)
+	putScratch(&utf16Scratch, buf, chars)
+	return ret
+}
+
+// NewStringFromUTF16 creates a string from UTF-16 code units, which are
+// copied. Unlike NewString, it can create strings containing unpaired
+// surrogates.
+func NewStringFromUTF16(chars []uint16) *String {
+	var ptr *C.JSChar
+	if len(chars) > 0 {
+		ptr = (*C.JSChar)(unsafe.Pointer(&chars[0]))
+	}
+	ref := C.JSStringCreateWithCharacters(ptr, C.size_t(len(chars)))
 	return (*String)(unsafe.Pointer(ref))
 }
 
@@ -34,34 +51,82 @@ func (ref *String) Release() {
 	C.JSStringRelease((C.JSStringRef)(unsafe.Pointer(ref)))
 }
 
+// chars returns the UTF-16 code units of the string without copying them.
+// The slice is only valid until the string is released.
+func (ref *String) chars() []uint16 {
+	r := (C.JSStringRef)(unsafe.Pointer(ref))
+	n := C.JSStringGetLength(r)
+	if n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*uint16)(unsafe.Pointer(C.JSStringGetCharactersPtr(r))), int(n))
+}
+
+// String converts the string to UTF-8. JavaScript strings are sequences of
+// UTF-16 code units and may contain unpaired surrogates, which have no UTF-8
+// form; String replaces each with U+FFFD. Use WTF8 or UTF16 to preserve
+// them.
 func (ref *String) String() string {
-	// Conversion 1, null-terminate UTF-8 string
-	len := C.JSStringGetMaximumUTF8CStringSize((C.JSStringRef)(unsafe.Pointer(ref)))
-	buffer := C.malloc(len)
-	if buffer == nil {
-		panic(syscall.ENOMEM)
+	return utf16ToString(ref.chars())
+}
+
+// UTF16 returns a copy of the UTF-16 code units of the string.
+func (ref *String) UTF16() []uint16 {
+	return append([]uint16(nil), ref.chars()...)
+}
+
+// WTF8 converts the string to WTF-8, which is UTF-8 except that unpaired
+// surrogates are encoded as if they were code points. The result is valid
+// UTF-8 exactly when the string has no unpaired surrogates, and
+// NewStringFromWTF8 converts it back without loss.
+func (ref *String) WTF8() string {
+	chars := ref.chars()
+	buf := make([]byte, 0, len(chars))
+	for i := 0; i < len(chars); i++ {
+		c := rune(chars[i])
+		if utf16.IsSurrogate(c) {
+			if i+1 < len(chars) {
+				if r := utf16.DecodeRune(c, rune(chars[i+1])); r != utf8.RuneError {
+					buf = utf8.AppendRune(buf, r)
+					i++
+					continue
+				}
+			}
+			buf = append(buf, 0xe0|byte(c>>12), 0x80|byte(c>>6)&0x3f, 0x80|byte(c)&0x3f)
+			continue
+		}
+		buf = utf8.AppendRune(buf, c)
 	}
-	defer C.free(buffer)
-	C.JSStringGetUTF8CString((C.JSStringRef)(unsafe.Pointer(ref)), (*C.char)(buffer), len)
+	return string(buf)
+}
 
-	// Conversion 2, Go string
-	ret := C.GoString((*C.char)(buffer))
-	return ret
+// NewStringFromWTF8 creates a string from WTF-8, as produced by WTF8,
+// restoring any unpaired surrogates. Other invalid UTF-8 becomes U+FFFD.
+func NewStringFromWTF8(value string) *String {
+	chars := make([]uint16, 0, len(value))
+	for i := 0; i < len(value); {
+		if isWTF8Surrogate(value[i:]) {
+			chars = append(chars, 0xd000|uint16(value[i+1]&0x3f)<<6|uint16(value[i+2]&0x3f))
+			i += 3
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(value[i:])
+		chars = utf16.AppendRune(chars, r)
+		i += size
+	}
+	return NewStringFromUTF16(chars)
+}
+
+// isWTF8Surrogate reports whether s starts with the generalized UTF-8
+// encoding of a surrogate, U+D800 to U+DFFF.
+func isWTF8Surrogate(s string) bool {
+	return len(s) >= 3 && s[0] == 0xed && s[1] >= 0xa0 && s[1] <= 0xbf && s[2]&0xc0 == 0x80
 }
 
 // Bytes returns a byte slice with the bytes of this string.
 func (ref *String) Bytes() []byte {
-	// Conversion 1, null-terminate UTF-8 string
-	len := C.JSStringGetMaximumUTF8CStringSize((C.JSStringRef)(unsafe.Pointer(ref)))
-	buffer := C.malloc(len)
-	if buffer == nil {
-		panic(syscall.ENOMEM)
-	}
-	defer C.free(buffer)
-	strlenWithNul := C.JSStringGetUTF8CString((C.JSStringRef)(unsafe.Pointer(ref)), (*C.char)(buffer), len)
-
-	// Conversion 2, Go []byte
-	return C.GoBytes(buffer, C.int(strlenWithNul-1))
+	chars := ref.chars()
+	return appendUTF8(make([]byte, 0, len(chars)), chars)
 }
 
 func (ref *String) Length() uint32 {
@@ -74,9 +139,81 @@ func (ref *String) Equal(rhs *String) bool {
 	return bool(ret)
 }
 
+// EqualToString reports whether the string is equal to the UTF-8 string rhs,
+// which may contain NULs.
 func (ref *String) EqualToString(rhs string) bool {
-	crhs := C.CString(rhs)
-	defer C.free(unsafe.Pointer(crhs))
-	ret := C.JSStringIsEqualToUTF8CString((C.JSStringRef)(unsafe.Pointer(ref)), crhs)
-	return bool(ret)
+	buf := utf16Scratch.Get().(*[]uint16)
+	chars := appendUTF16((*buf)[:0], rhs)
+	ret := slices.Equal(ref.chars(), chars)
+	putScratch(&utf16Scratch, buf, chars)
+	return ret
+}
+
+//=========================================================
+// Transcoding
+//
+
+// Scratch buffers for transcoding, so that converting a string allocates
+// only the result. Buffers that grew past maxScratch are not kept.
+var (
+	utf8Scratch  = sync.Pool{New: func() interface{} { return new([]byte) }}
+	utf16Scratch = sync.Pool{New: func() interface{} { return new([]uint16) }}
+)
+
+const maxScratch = 64 << 10
+
+func putScratch[T byte | uint16](pool *sync.Pool, buf *[]T, used []T) {
+	if cap(used) > maxScratch {
+		return
+	}
+	*buf = used
+	pool.Put(buf)
+}
+
+// utf16ToString converts UTF-16 text to a Go string, copying it once.
+func utf16ToString(s []uint16) string {
+	buf := utf8Scratch.Get().(*[]byte)
+	b := appendUTF8((*buf)[:0], s)
+	ret := string(b)
+	putScratch(&utf8Scratch, buf, b)
+	return ret
+}
+
+// appendUTF8 appends the UTF-8 encoding of the UTF-16 text s to buf.
+// Unpaired surrogates become U+FFFD.
+func appendUTF8(buf []byte, s []uint16) []byte {
+	for i := 0; i < len(s); i++ {
+		c := rune(s[i])
+		switch {
+		case c < utf8.RuneSelf:
+			buf = append(buf, byte(c))
+		case utf16.IsSurrogate(c):
+			r := utf8.RuneError
+			if i+1 < len(s) {
+				if d := utf16.DecodeRune(c, rune(s[i+1])); d != utf8.RuneError {
+					r = d
+					i++
+				}
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			buf = utf8.AppendRune(buf, c)
+		}
+	}
+	return buf
+}
+
+// appendUTF16 appends the UTF-16 encoding of s to buf. Invalid UTF-8
+// becomes U+FFFD.
+func appendUTF16(buf []uint16, s string) []uint16 {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < utf8.RuneSelf {
+			buf = append(buf, uint16(c))
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		buf = utf16.AppendRune(buf, r)
+		i += size - 1
+	}
+	return buf
 }
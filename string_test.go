@@ -2,6 +2,7 @@ package gojs
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 )
 
@@ -91,3 +92,146 @@ func TestStringEqualToString(t *testing.T) {
 		}
 	}
 }
+
+func TestNewStringFromUTF16(t *testing.T) {
+	tests := []struct {
+		chars []uint16
+		want  string
+	}{
+		{nil, ""},
+		{[]uint16{'h', 'i'}, "hi"},
+		{[]uint16{0xd83d, 0xde00}, "\U0001f600"},
+		{[]uint16{'a', 0xd83d, 'b'}, "a�b"},
+		{[]uint16{0xde00}, "�"},
+	}
+	for _, test := range tests {
+		str := NewStringFromUTF16(test.chars)
+		defer str.Release()
+
+		if got := str.String(); got != test.want {
+			t.Errorf("NewStringFromUTF16(%x).String() = %q, want %q", test.chars, got, test.want)
+		}
+		if got := str.Length(); got != uint32(len(test.chars)) {
+			t.Errorf("NewStringFromUTF16(%x).Length() = %d, want %d", test.chars, got, len(test.chars))
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, item := range append(strtests, "", "\U0001f600 emoji", "é", string(make([]byte, 70000))) {
+		str := NewString(item)
+		defer str.Release()
+
+		if got := str.String(); got != item {
+			t.Errorf("NewString(%.20q).String() = %.20q", item, got)
+		}
+		if got := string(str.Bytes()); got != item {
+			t.Errorf("NewString(%.20q).Bytes() = %.20q", item, got)
+		}
+	}
+}
+
+var benchmarkStrings = map[string]string{
+	"ASCII":   "propertyName",
+	"Unicode": "日本語 \U0001f600 café",
+	"Long":    string(bytes.Repeat([]byte("abcdefgh"), 1024)),
+}
+
+func BenchmarkStringString(b *testing.B) {
+	for name, item := range benchmarkStrings {
+		b.Run(name, func(b *testing.B) {
+			str := NewString(item)
+			defer str.Release()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = str.String()
+			}
+		})
+	}
+}
+
+func BenchmarkNewString(b *testing.B) {
+	for name, item := range benchmarkStrings {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewString(item).Release()
+			}
+		})
+	}
+}
+
+// BenchmarkNativeGetProperty measures reading a field of a native object,
+// which converts the property name on every access.
+func BenchmarkNativeGetProperty(b *testing.B) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	obj := ctx.NewNativeObject(&struct{ PropertyName int }{42})
+	fn, err := ctx.EvaluateScript("(function (o) { return o.PropertyName; })", nil, "", 1)
+	if err != nil {
+		b.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	get := fn.ToObjectOrDie()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := get.Call(nil, obj.ToValue()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStringNUL(t *testing.T) {
+	item := "a\x00b"
+	str := NewString(item)
+	defer str.Release()
+
+	if str.Length() != 3 || str.String() != item {
+		t.Errorf("NewString(%q) = %q, length %d", item, str.String(), str.Length())
+	}
+	if !str.EqualToString(item) {
+		t.Errorf("str.EqualToString(%q) = false", item)
+	}
+	if str.EqualToString("a") {
+		t.Errorf("str.EqualToString(%q) = true", "a")
+	}
+
+	ctx := NewContext()
+	defer ctx.Release()
+	if got := ctx.NewStringValue(item).String(); got != item {
+		t.Errorf("ctx.NewStringValue(%q) = %q", item, got)
+	}
+}
+
+func TestStringSurrogates(t *testing.T) {
+	chars := []uint16{'x', 0xd800, 0xd83d, 0xde00, 0xdfff}
+	str := NewStringFromUTF16(chars)
+	defer str.Release()
+
+	if got := str.UTF16(); !reflect.DeepEqual(got, chars) {
+		t.Errorf("str.UTF16() = %x, want %x", got, chars)
+	}
+	if got, want := str.String(), "x�\U0001f600�"; got != want {
+		t.Errorf("str.String() = %q, want %q", got, want)
+	}
+	wtf8 := str.WTF8()
+	if want := "x\xed\xa0\x80\U0001f600\xed\xbf\xbf"; wtf8 != want {
+		t.Errorf("str.WTF8() = %q, want %q", wtf8, want)
+	}
+
+	back := NewStringFromWTF8(wtf8)
+	defer back.Release()
+	if !back.Equal(str) {
+		t.Errorf("NewStringFromWTF8(%q) = %x, want %x", wtf8, back.UTF16(), chars)
+	}
+
+	// Without unpaired surrogates WTF-8 is UTF-8.
+	for _, item := range strtests {
+		s := NewString(item)
+		defer s.Release()
+		if s.WTF8() != item {
+			t.Errorf("NewString(%q).WTF8() = %q", item, s.WTF8())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gojs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WellKnownSymbol names one of the symbols defined as properties of the
+// Symbol constructor, such as Symbol.iterator.
+type WellKnownSymbol string
+
+const (
+	SymbolAsyncIterator      WellKnownSymbol = "asyncIterator"
+	SymbolHasInstance        WellKnownSymbol = "hasInstance"
+	SymbolIsConcatSpreadable WellKnownSymbol = "isConcatSpreadable"
+	SymbolIterator           WellKnownSymbol = "iterator"
+	SymbolMatch              WellKnownSymbol = "match"
+	SymbolReplace            WellKnownSymbol = "replace"
+	SymbolSearch             WellKnownSymbol = "search"
+	SymbolSpecies            WellKnownSymbol = "species"
+	SymbolSplit              WellKnownSymbol = "split"
+	SymbolToPrimitive        WellKnownSymbol = "toPrimitive"
+	SymbolToStringTag        WellKnownSymbol = "toStringTag"
+	SymbolUnscopables        WellKnownSymbol = "unscopables"
+)
+
+// NewSymbol creates a new unique symbol, as Symbol(description) does.
+func (ctx *Context) NewSymbol(description string) (*Value, error) {
+	return ctx.callHelper("(function (Symbol) { return function (d) { return Symbol(d); }; })(Symbol)", ctx.NewStringValue(description))
+}
+
+// WellKnownSymbol returns the symbol Symbol[name]. It fails if the
+// JavaScriptCore in use does not define that symbol.
+func (ctx *Context) WellKnownSymbol(name WellKnownSymbol) (*Value, error) {
+	ret, err := ctx.callHelper("(function (Symbol) { return function (n) { return Symbol ? Symbol[n] : undefined; }; })(typeof Symbol === 'function' ? Symbol : undefined)", ctx.NewStringValue(string(name)))
+	if err != nil {
+		return nil, err
+	}
+	if !ret.IsSymbol() {
+		return nil, fmt.Errorf("gojs: Symbol.%s is not supported", name)
+	}
+	return ret, nil
+}
+
+// Description returns the description the symbol v was created with.
+func (v *Value) Description() (string, error) {
+	if !v.IsSymbol() {
+		return "", fmt.Errorf("gojs: value of type %d is not a symbol", v.Type())
+	}
+	// String(Symbol(d)) is always "Symbol(d)".
+	ret, err := v.ctx.callHelper("(function (String) { return function (s) { return String(s); }; })(String)", v)
+	if err != nil {
+		return "", err
+	}
+	str, err := ret.ToString()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(str, "Symbol("), ")"), nil
+}
+
+// GetBySymbol returns the property of obj keyed by the symbol sym.
+func (obj *Object) GetBySymbol(sym *Value) (*Value, error) {
+	sym, err := obj.symbolKey("Object.GetBySymbol", sym)
+	if err != nil {
+		return nil, err
+	}
+	return obj.ctx.callHelper("function (o, k) { return o[k]; }", obj.ToValue(), sym)
+}
+
+// SetBySymbol assigns the property of obj keyed by the symbol sym.
+func (obj *Object) SetBySymbol(sym *Value, v *Value) error {
+	sym, err := obj.symbolKey("Object.SetBySymbol", sym)
+	if err != nil {
+		return err
+	}
+	v, err = obj.ctx.adopt("Object.SetBySymbol", v)
+	if err != nil {
+		return err
+	}
+	_, err = obj.ctx.callHelper("function (o, k, v) { o[k] = v; }", obj.ToValue(), sym, v)
+	return err
+}
+
+// HasBySymbol reports whether obj or its prototype chain has a property
+// keyed by the symbol sym.
+func (obj *Object) HasBySymbol(sym *Value) bool {
+	sym, err := obj.symbolKey("Object.HasBySymbol", sym)
+	if err != nil {
+		return false
+	}
+	ret, err := obj.ctx.callHelper("function (o, k) { return k in o; }", obj.ToValue(), sym)
+	return err == nil && ret.ToBoolean()
+}
+
+func (obj *Object) symbolKey(op string, sym *Value) (*Value, error) {
+	sym, err := obj.ctx.adopt(op, sym)
+	if err != nil {
+		return nil, err
+	}
+	if sym == nil || !sym.IsSymbol() {
+		return nil, fmt.Errorf("gojs: %s: key is not a symbol", op)
+	}
+	return sym, nil
+}
@@ -0,0 +1,93 @@
+package gojs
+
+import (
+	"testing"
+)
+
+func TestNewSymbol(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	skipWithout(t, ctx, "Symbol")
+
+	a, err := ctx.NewSymbol("tag")
+	if err != nil {
+		t.Fatalf("ctx.NewSymbol returned an error (%v)", err)
+	}
+	b, _ := ctx.NewSymbol("tag")
+	if a.Type() != TypeSymbol || !a.IsSymbol() || a.IsObject() || a.IsString() {
+		t.Errorf("ctx.NewSymbol returned a value of type %d", a.Type())
+	}
+	if a.StrictEquals(b) {
+		t.Errorf("two symbols with the same description are equal")
+	}
+	if desc, err := a.Description(); err != nil || desc != "tag" {
+		t.Errorf("a.Description returned %q, %v", desc, err)
+	}
+	if got := a.Inspect(nil); got != "Symbol(tag)" {
+		t.Errorf("a.Inspect returned %q", got)
+	}
+	if _, err := a.ToString(); err == nil {
+		t.Errorf("converting a symbol to a string did not fail")
+	}
+}
+
+func TestSymbolProperties(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	skipWithout(t, ctx, "Symbol")
+
+	sym, _ := ctx.NewSymbol("key")
+	obj := ctx.NewEmptyObject()
+	if err := obj.SetBySymbol(sym, ctx.NewNumberValue(1)); err != nil {
+		t.Fatalf("obj.SetBySymbol returned an error (%v)", err)
+	}
+	if v, err := obj.GetBySymbol(sym); err != nil || v.ToNumberOrDie() != 1 {
+		t.Errorf("obj.GetBySymbol returned %v, %v", v, err)
+	}
+	if !obj.HasBySymbol(sym) || len(obj.Keys()) != 0 {
+		t.Errorf("symbol-keyed property is missing or listed as a name")
+	}
+	if got := obj.ToValue().Inspect(nil); got != "{ [Symbol(key)]: 1 }" {
+		t.Errorf("obj.Inspect returned %q", got)
+	}
+	if err := obj.SetBySymbol(ctx.NewStringValue("key"), ctx.NewNullValue()); err == nil {
+		t.Errorf("obj.SetBySymbol with a string key did not fail")
+	}
+}
+
+func TestWellKnownSymbols(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+	skipWithout(t, ctx, "Symbol")
+
+	iterator, err := ctx.WellKnownSymbol(SymbolIterator)
+	if err != nil {
+		t.Fatalf("ctx.WellKnownSymbol returned an error (%v)", err)
+	}
+
+	// An object with a Symbol.iterator method works with for...of.
+	obj := ctx.NewEmptyObject()
+	next, err := ctx.EvaluateScript("(function () { var i = 0; return { next: function () { i++; return { done: i > 3, value: i }; } }; })", nil, "./symbol_test.go", 1)
+	if err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if err := obj.SetBySymbol(iterator, next); err != nil {
+		t.Fatalf("obj.SetBySymbol returned an error (%v)", err)
+	}
+	ctx.GlobalObject().Set("counter", obj.ToValue())
+	ret, err := ctx.EvaluateScript("var sum = 0; for (var n of counter) sum += n; sum", nil, "./symbol_test.go", 1)
+	if err != nil || ret.ToNumberOrDie() != 6 {
+		t.Errorf("iterating the object returned %v, %v", ret, err)
+	}
+
+	toPrimitive, err := ctx.WellKnownSymbol(SymbolToPrimitive)
+	if err != nil {
+		t.Fatalf("ctx.WellKnownSymbol returned an error (%v)", err)
+	}
+	obj.SetBySymbol(toPrimitive, ctx.NewFunctionWithCallback(func(ctx *Context, _, _ *Object, _ []*Value) *Value {
+		return ctx.NewNumberValue(42)
+	}).ToValue())
+	if n, err := obj.ToValue().ToNumber(); err != nil || n != 42 {
+		t.Errorf("Symbol.toPrimitive was not used for conversion (%v, %v)", n, err)
+	}
+}
@@ -0,0 +1,89 @@
+package gojs
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// TraceEvent selects the kinds of internal events reported to a tracer set
+// with SetTracer.
+type TraceEvent uint
+
+const (
+	// TraceEval reports scripts passed to EvaluateScript and their failures.
+	TraceEval TraceEvent = 1 << iota
+	// TraceNativeCall reports calls from JavaScript into Go functions,
+	// callbacks and methods.
+	TraceNativeCall
+	// TraceConversion reports conversions between JavaScript values and Go
+	// values, including writes to native object fields.
+	TraceConversion
+	// TraceGC reports garbage collections requested through GarbageCollect.
+	TraceGC
+
+	TraceAll = TraceEval | TraceNativeCall | TraceConversion | TraceGC
+)
+
+func (e TraceEvent) String() string {
+	switch e {
+	case TraceEval:
+		return "eval"
+	case TraceNativeCall:
+		return "native-call"
+	case TraceConversion:
+		return "conversion"
+	case TraceGC:
+		return "gc"
+	}
+	return "multiple"
+}
+
+type tracer struct {
+	logger *slog.Logger
+	events TraceEvent
+}
+
+// activeTracers counts the contexts with a tracer installed, so that the
+// common untraced case costs a single atomic load.
+var activeTracers int32
+
+// SetTracer sends the selected events for this context to logger. All events
+// are logged at slog.LevelDebug with an "event" attribute naming their kind.
+// Passing a nil logger or no events turns tracing off, which is the default.
+//
+// The tracer is shared by every *Context wrapping the same global context,
+// including those handed to native callbacks.
+func (ctx *Context) SetTracer(logger *slog.Logger, events TraceEvent) {
+	var t *tracer
+	if logger != nil && events != 0 {
+		t = &tracer{logger, events}
+	}
+
+	if t != nil {
+		atomic.AddInt32(&activeTracers, 1)
+	}
+	if old := ctx.state().tracer.Swap(t); old != nil {
+		atomic.AddInt32(&activeTracers, -1)
+	}
+}
+
+// tracing returns the logger to use for event, or nil if the event is not
+// being traced for this context.
+func (ctx *Context) tracing(event TraceEvent) *slog.Logger {
+	if atomic.LoadInt32(&activeTracers) == 0 {
+		return nil
+	}
+	t := ctx.state().tracer.Load()
+	if t == nil || t.events&event == 0 {
+		return nil
+	}
+	return t.logger
+}
+
+// trace logs msg for event if the context is tracing it.
+func (ctx *Context) trace(event TraceEvent, msg string, args ...interface{}) {
+	if logger := ctx.tracing(event); logger != nil {
+		logger.Log(context.Background(), slog.LevelDebug, msg, append([]interface{}{"event", event.String()}, args...)...)
+	}
+}
@@ -0,0 +1,73 @@
+package gojs
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newTestTracer(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestSetTracer(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	var buf bytes.Buffer
+	ctx.SetTracer(newTestTracer(&buf), TraceEval|TraceNativeCall)
+
+	fn := ctx.NewFunctionWithNative(func(a float64) float64 { return a * 2 })
+	ctx.SetProperty(ctx.GlobalObject(), "double", fn.ToValue(), 0)
+	if _, err := ctx.EvaluateScript("double(2)", nil, "./trace_test.go", 1); err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	ctx.GarbageCollect()
+
+	out := buf.String()
+	if !strings.Contains(out, "event=eval") || !strings.Contains(out, "sourceURL=./trace_test.go") {
+		t.Errorf("tracer did not log the evaluation: %q", out)
+	}
+	if !strings.Contains(out, "event=native-call") {
+		t.Errorf("tracer did not log the native call: %q", out)
+	}
+	if strings.Contains(out, "event=gc") || strings.Contains(out, "event=conversion") {
+		t.Errorf("tracer logged events that were not selected: %q", out)
+	}
+	if strings.Contains(out, "double(2)") {
+		t.Errorf("tracer leaked the script source: %q", out)
+	}
+
+	buf.Reset()
+	ctx.SetTracer(nil, TraceAll)
+	if _, err := ctx.EvaluateScript("double(3)", nil, "./trace_test.go", 1); err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("tracer still logging after being removed: %q", buf.String())
+	}
+}
+
+func TestTracerSharedWithCallbacks(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	var buf bytes.Buffer
+	ctx.SetTracer(newTestTracer(&buf), TraceEval)
+
+	callback := func(ctx *Context, _, _ *Object, _ []*Value) *Value {
+		ret, err := ctx.EvaluateScript("1", nil, "./nested.js", 1)
+		if err != nil {
+			panic(err)
+		}
+		return ret
+	}
+	ctx.SetProperty(ctx.GlobalObject(), "nested", ctx.NewFunctionWithCallback(callback).ToValue(), 0)
+	if _, err := ctx.EvaluateScript("nested()", nil, "./trace_test.go", 1); err != nil {
+		t.Fatalf("ctx.EvaluateScript returned an error (%v)", err)
+	}
+	if !strings.Contains(buf.String(), "sourceURL=./nested.js") {
+		t.Errorf("tracer was not used by the context passed to a callback: %q", buf.String())
+	}
+}
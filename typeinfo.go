@@ -0,0 +1,94 @@
+package gojs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeInfo holds the reflection metadata of a Go type wrapped by native
+// objects. It is computed once per type and shared by all contexts.
+type typeInfo struct {
+	// fields is set for struct types.
+	fields *fieldTable
+	// methods lists the exported methods of the type, by index.
+	methods  []string
+	iterable bool
+}
+
+// typeInfos caches a *typeInfo for each reflect.Type.
+var typeInfos sync.Map
+
+// typeInfoFor returns the metadata of typ, computing it on first use.
+func typeInfoFor(typ reflect.Type) *typeInfo {
+	if info, ok := typeInfos.Load(typ); ok {
+		return info.(*typeInfo)
+	}
+	info := &typeInfo{
+		methods:  make([]string, typ.NumMethod()),
+		iterable: isIterableType(typ),
+	}
+	if typ.Kind() == reflect.Struct {
+		info.fields = newFieldTable(typ)
+	}
+	for i := range info.methods {
+		info.methods[i] = typ.Method(i).Name
+	}
+	actual, _ := typeInfos.LoadOrStore(typ, info)
+	return actual.(*typeInfo)
+}
+
+// nativePrototype returns the prototype shared by the native objects of
+// type typ in this context. It holds a method object for each method of
+// typ, so that a method is the same function however often it is read, a
+// toJSON method serialising the Go value, and a Symbol.toStringTag naming
+// the Go type. It inherits from the iterable prototype where typ is
+// iterable, and is protected until the context is released.
+//
+// The tag is left out where it cannot be defined, as after Terminate or
+// with a replaced Object.defineProperty. An error adding the methods is
+// returned, and the prototype is not cached.
+func (ctx *Context) nativePrototype(typ reflect.Type) (*Object, error) {
+	info := typeInfoFor(typ)
+	s := ctx.state()
+	s.mu.Lock()
+	proto := s.prototypes[typ]
+	s.mu.Unlock()
+	if proto != nil {
+		return ctx.newObject(proto.ref), nil
+	}
+
+	proto = ctx.NewEmptyObject()
+	if info.iterable {
+		if parent := ctx.iterablePrototype(); parent != nil {
+			proto.SetPrototype(parent.ToValue())
+		}
+	}
+	for i, name := range info.methods {
+		method := newNativeMethod(ctx, typ, i)
+		if err := proto.SetWithAttributes(name, method.ToValue(), PropertyAttributeDontEnum); err != nil {
+			return nil, err
+		}
+	}
+	toJSON := ctx.NewFunctionWithCallback(toJSONCallback)
+	if err := proto.SetWithAttributes("toJSON", toJSON.ToValue(), PropertyAttributeDontEnum); err != nil {
+		return nil, err
+	}
+	ctx.callHelper(`(function (defineProperty, toStringTag) {
+		return function (proto, tag) {
+			if (toStringTag) defineProperty(proto, toStringTag, { value: tag, configurable: true });
+		};
+	})(Object.defineProperty, typeof Symbol === 'function' ? Symbol.toStringTag : undefined)`, proto.ToValue(), ctx.NewStringValue(goTypeName(typ)))
+	proto.ToValue().Protect()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.prototypes == nil {
+		s.prototypes = make(map[reflect.Type]*Object)
+	}
+	if existing := s.prototypes[typ]; existing != nil {
+		proto.ToValue().Unprotect()
+		return ctx.newObject(existing.ref), nil
+	}
+	s.prototypes[typ] = proto
+	return ctx.newObject(proto.ref), nil
+}
@@ -0,0 +1,44 @@
+package gojs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypeInfoFor(t *testing.T) {
+	typ := reflect.TypeOf(&reflect_object{})
+	info := typeInfoFor(typ)
+	if typeInfoFor(typ) != info {
+		t.Errorf("typeInfoFor did not cache the metadata of %s", typ)
+	}
+	want := []string{"Add", "AddWith", "Null", "Self", "String"}
+	if !reflect.DeepEqual(info.methods, want) {
+		t.Errorf("typeInfoFor(%s).methods = %v, want %v", typ, info.methods, want)
+	}
+	if info.fields != nil {
+		t.Errorf("typeInfoFor(%s).fields is set for a pointer type", typ)
+	}
+	if names := fieldNames(typ.Elem()); len(names) == 0 {
+		t.Errorf("fieldNames(%s) is empty", typ.Elem())
+	}
+}
+
+type typeinfo_untagged struct{ N int }
+
+func TestNativePrototypeWithoutTag(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	// The first object of a type made after a script replaces
+	// Object.defineProperty gets a prototype without the tag.
+	if _, err := ctx.EvaluateScript("Object.defineProperty = function () { throw new Error('no'); }", nil, "", 1); err != nil {
+		t.Fatalf("ctx.EvaluateScript failed: %v", err)
+	}
+	obj := ctx.NewNativeObject(&typeinfo_untagged{3})
+	if v, err := obj.Get("N"); err != nil || v.String() != "3" {
+		t.Errorf("obj.N = %v, %v", v, err)
+	}
+	if v, err := obj.Get("toJSON"); err != nil || !v.IsFunction() {
+		t.Errorf("obj.toJSON = %v, %v, want the prototype's method", v, err)
+	}
+}
@@ -3,9 +3,9 @@ package gojs
 // #include <stdlib.h>
 // #include <JavaScriptCore/JSStringRef.h>
 // #include <JavaScriptCore/JSValueRef.h>
+// #include <JavaScriptCore/JSObjectRef.h>
 import "C"
 import (
-	"encoding/json"
 	"fmt"
 	"unsafe"
 )
@@ -22,81 +22,171 @@ const (
 	TypeNumber    = iota
 	TypeString    = iota
 	TypeObject    = iota
+	TypeSymbol    = iota
+	TypeBigInt    = iota
 )
 
 func (val *Value) String() string {
-	str, err := val.ctx.ToString(val)
+	str, err := val.ToString()
 	if err != nil {
 		return "Error:" + err.Error()
 	}
 	return str
 }
 
-// GoVal converts a JavaScript value to a Go value. TODO(sqs): might it be
-// easier to just have JavaScriptCore serialize this to JSON and then
-// deserialize it in Go?
+// GoValue converts a JavaScript value to a Go value: nil, bool, float64,
+// string, *big.Int for BigInts, time.Time for dates, []interface{} for
+// arrays and Sets, map[interface{}]interface{} for Maps and
+// map[string]interface{} for other objects. Objects are read in a single
+// call into JavaScriptCore; see Decode for the details.
 func (v *Value) GoValue() (goval interface{}, err error) {
-	switch v.ctx.ValueType(v) {
+	switch v.Type() {
 	case TypeUndefined, TypeNull:
 		return nil, nil
 	case TypeBoolean:
-		return v.ctx.ToBoolean(v), nil
+		return v.ToBoolean(), nil
 	case TypeNumber:
-		return v.ctx.ToNumber(v)
+		return v.ToNumber()
 	case TypeString:
-		return v.ctx.ToString(v)
+		return v.ToString()
+	case TypeBigInt:
+		return v.ToBigInt()
 	case TypeObject:
-		jsonData, err := v.JSON()
+		t, err := v.ctx.flatten(v)
 		if err != nil {
 			return nil, err
 		}
-		err = json.Unmarshal(jsonData, &goval)
+		goval, _, err = t.value(0)
 		return goval, err
 	}
-	return nil, fmt.Errorf("JS value type %d is not convertible to a Go value", v.ctx.ValueType(v))
+	return nil, fmt.Errorf("JS value type %d is not convertible to a Go value", v.Type())
 }
 
+// Context returns the context that v belongs to.
+func (v *Value) Context() *Context {
+	return v.ctx
+}
+
+// Type returns the JavaScript type of v, one of the Type constants.
+func (v *Value) Type() uint8 {
+	t := uint8(C.JSValueGetType(v.ctx.ref, v.ref))
+	// Versions of JavaScriptCore that predate kJSTypeSymbol and
+	// kJSTypeBigInt report symbols and BigInts as objects, although
+	// JSValueIsObject is false for them.
+	if t == TypeObject && !v.IsObject() {
+		ret, err := v.ctx.callHelper("function (v) { return typeof v === 'bigint'; }", v)
+		if err == nil && ret.ToBoolean() {
+			return TypeBigInt
+		}
+		return TypeSymbol
+	}
+	return t
+}
+
+func (v *Value) IsUndefined() bool {
+	return bool(C.JSValueIsUndefined(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsNull() bool {
+	return bool(C.JSValueIsNull(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsBoolean() bool {
+	return bool(C.JSValueIsBoolean(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsNumber() bool {
+	return bool(C.JSValueIsNumber(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsString() bool {
+	return bool(C.JSValueIsString(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsObject() bool {
+	return bool(C.JSValueIsObject(v.ctx.ref, v.ref))
+}
+
+func (v *Value) IsSymbol() bool {
+	return v.Type() == TypeSymbol
+}
+
+// IsFunction reports whether v is an object that can be called.
+func (v *Value) IsFunction() bool {
+	return v.IsObject() && bool(C.JSObjectIsFunction(v.ctx.ref, C.JSObjectRef(unsafe.Pointer(v.ref))))
+}
+
+// Equals reports whether v == other, following the JavaScript rules for
+// loose equality. The comparison may call valueOf or toString, which can
+// throw.
+func (v *Value) Equals(other *Value) (bool, error) {
+	other, err := v.ctx.adopt("Value.Equals", other)
+	if err != nil {
+		return false, err
+	}
+
+	errVal := v.ctx.newErrorValue()
+	ret := C.JSValueIsEqual(v.ctx.ref, v.ref, other.ref, &errVal.ref)
+	if errVal.ref != nil {
+		return false, errVal
+	}
+
+	return bool(ret), nil
+}
+
+// StrictEquals reports whether v === other. Objects from different context
+// groups are never equal.
+func (v *Value) StrictEquals(other *Value) bool {
+	other, err := v.ctx.adopt("Value.StrictEquals", other)
+	if err != nil {
+		return false
+	}
+	return bool(C.JSValueIsStrictEqual(v.ctx.ref, v.ref, other.ref))
+}
+
+// Deprecated: use v.Type.
 func (ctx *Context) ValueType(v *Value) uint8 {
-	return uint8(C.JSValueGetType(ctx.ref, v.ref))
+	return v.Type()
 }
 
+// Deprecated: use v.IsUndefined.
 func (ctx *Context) IsUndefined(v *Value) bool {
-	return bool(C.JSValueIsUndefined(ctx.ref, v.ref))
+	return v.IsUndefined()
 }
 
+// Deprecated: use v.IsNull.
 func (ctx *Context) IsNull(v *Value) bool {
-	return bool(C.JSValueIsNull(ctx.ref, v.ref))
+	return v.IsNull()
 }
 
+// Deprecated: use v.IsBoolean.
 func (ctx *Context) IsBoolean(v *Value) bool {
-	return bool(C.JSValueIsBoolean(ctx.ref, v.ref))
+	return v.IsBoolean()
 }
 
+// Deprecated: use v.IsNumber.
 func (ctx *Context) IsNumber(v *Value) bool {
-	return bool(C.JSValueIsNumber(ctx.ref, v.ref))
+	return v.IsNumber()
 }
 
+// Deprecated: use v.IsString.
 func (ctx *Context) IsString(v *Value) bool {
-	return bool(C.JSValueIsString(ctx.ref, v.ref))
+	return v.IsString()
 }
 
+// Deprecated: use v.IsObject.
 func (ctx *Context) IsObject(v *Value) bool {
-	ret := C.JSValueIsObject(ctx.ref, v.ref)
-	return bool(ret)
+	return v.IsObject()
 }
 
+// Deprecated: use a.Equals.
 func (ctx *Context) IsEqual(a *Value, b *Value) (bool, error) {
-	errVal := ctx.newErrorValue()
-	ret := C.JSValueIsEqual(ctx.ref, a.ref, b.ref, &errVal.ref)
-	if errVal.ref != nil {
-		return false, errVal
-	}
-
-	return bool(ret), nil
+	return a.Equals(b)
 }
 
+// Deprecated: use a.StrictEquals.
 func (ctx *Context) IsStrictEqual(a *Value, b *Value) bool {
-	return bool(C.JSValueIsStrictEqual(ctx.ref, a.ref, b.ref))
+	return a.StrictEquals(b)
 }
 
 func (ctx *Context) newValue(ref C.JSValueRef) *Value {
@@ -132,22 +222,20 @@ func (ctx *Context) NewNumberValue(value float64) *Value {
 }
 
 func (ctx *Context) NewStringValue(value string) *Value {
-	cvalue := C.CString(value)
-	defer C.free(unsafe.Pointer(cvalue))
-	jsstr := C.JSStringCreateWithUTF8CString(cvalue)
-	defer C.JSStringRelease(jsstr)
-	ref := C.JSValueMakeString(ctx.ref, jsstr)
+	jsstr := NewString(value)
+	defer jsstr.Release()
+	ref := C.JSValueMakeString(ctx.ref, C.JSStringRef(unsafe.Pointer(jsstr)))
 	return ctx.newValue(ref)
 }
 
-// TODO: Move to Value struct
-func (ctx *Context) ToBoolean(ref *Value) bool {
-	return bool(C.JSValueToBoolean(ctx.ref, ref.ref))
+func (v *Value) ToBoolean() bool {
+	return bool(C.JSValueToBoolean(v.ctx.ref, v.ref))
 }
 
-func (ctx *Context) ToNumber(ref *Value) (num float64, err error) {
-	errVal := ctx.newErrorValue()
-	ret := C.JSValueToNumber(ctx.ref, ref.ref, &errVal.ref)
+// ToNumber converts v to a number as the unary + operator would.
+func (v *Value) ToNumber() (float64, error) {
+	errVal := v.ctx.newErrorValue()
+	ret := C.JSValueToNumber(v.ctx.ref, v.ref, &errVal.ref)
 	if errVal.ref != nil {
 		return float64(ret), errVal
 	}
@@ -156,17 +244,18 @@ func (ctx *Context) ToNumber(ref *Value) (num float64, err error) {
 	return float64(ret), nil
 }
 
-func (ctx *Context) ToNumberOrDie(ref *Value) float64 {
-	ret, err := ctx.ToNumber(ref)
+func (v *Value) ToNumberOrDie() float64 {
+	ret, err := v.ToNumber()
 	if err != nil {
 		panic(err)
 	}
 	return ret
 }
 
-func (ctx *Context) ToString(ref *Value) (str string, err error) {
-	errVal := ctx.newErrorValue()
-	ret := C.JSValueToStringCopy(ctx.ref, ref.ref, &errVal.ref)
+// ToString converts v to a string as String(v) would.
+func (v *Value) ToString() (string, error) {
+	errVal := v.ctx.newErrorValue()
+	ret := C.JSValueToStringCopy(v.ctx.ref, v.ref, &errVal.ref)
 	if errVal.ref != nil {
 		return "", errVal
 	}
@@ -174,33 +263,70 @@ func (ctx *Context) ToString(ref *Value) (str string, err error) {
 	return newStringFromRef(ret).String(), nil
 }
 
-func (ctx *Context) ToStringOrDie(ref *Value) string {
-	str, err := ctx.ToString(ref)
+func (v *Value) ToStringOrDie() string {
+	str, err := v.ToString()
 	if err != nil {
 		panic(err)
 	}
 	return str
 }
 
-func (ctx *Context) ToObject(ref *Value) (*Object, error) {
-	errVal := ctx.newErrorValue()
-	ret := C.JSValueToObject(ctx.ref, ref.ref, &errVal.ref)
+// ToObject converts v to an object, boxing primitives. It fails for null
+// and undefined.
+func (v *Value) ToObject() (*Object, error) {
+	errVal := v.ctx.newErrorValue()
+	ret := C.JSValueToObject(v.ctx.ref, v.ref, &errVal.ref)
 	if errVal.ref != nil {
 		return nil, errVal
 	}
 
 	// Successful conversion
-	return ctx.newObject(ret), nil
+	return v.ctx.newObject(ret), nil
 }
 
-func (ctx *Context) ToObjectOrDie(ref *Value) *Object {
-	ret, err := ctx.ToObject(ref)
+func (v *Value) ToObjectOrDie() *Object {
+	ret, err := v.ToObject()
 	if err != nil {
 		panic(err)
 	}
 	return ret
 }
 
+// Deprecated: use ref.ToBoolean.
+func (ctx *Context) ToBoolean(ref *Value) bool {
+	return ref.ToBoolean()
+}
+
+// Deprecated: use ref.ToNumber.
+func (ctx *Context) ToNumber(ref *Value) (num float64, err error) {
+	return ref.ToNumber()
+}
+
+// Deprecated: use ref.ToNumberOrDie.
+func (ctx *Context) ToNumberOrDie(ref *Value) float64 {
+	return ref.ToNumberOrDie()
+}
+
+// Deprecated: use ref.ToString.
+func (ctx *Context) ToString(ref *Value) (str string, err error) {
+	return ref.ToString()
+}
+
+// Deprecated: use ref.ToStringOrDie.
+func (ctx *Context) ToStringOrDie(ref *Value) string {
+	return ref.ToStringOrDie()
+}
+
+// Deprecated: use ref.ToObject.
+func (ctx *Context) ToObject(ref *Value) (*Object, error) {
+	return ref.ToObject()
+}
+
+// Deprecated: use ref.ToObjectOrDie.
+func (ctx *Context) ToObjectOrDie(ref *Value) *Object {
+	return ref.ToObjectOrDie()
+}
+
 // JSON returns the JSON representation of the JavaScript value.
 func (v *Value) JSON() ([]byte, error) {
 	errVal := v.ctx.newErrorValue()
@@ -212,10 +338,28 @@ func (v *Value) JSON() ([]byte, error) {
 	return (*String)(unsafe.Pointer(jsstr)).Bytes(), nil
 }
 
+// ParseJSON parses data as JSON, as JSON.parse does, returning the
+// SyntaxError thrown for invalid input.
+func (ctx *Context) ParseJSON(data []byte) (*Value, error) {
+	return ctx.callHelper("(function (parse) { return function (s) { return parse(s); }; })(JSON.parse)", ctx.NewStringValue(string(data)))
+}
+
+// Protect keeps v from being garbage collected until a matching call to
+// Unprotect. Calls nest.
+func (v *Value) Protect() {
+	C.JSValueProtect(v.ctx.ref, v.ref)
+}
+
+func (v *Value) Unprotect() {
+	C.JSValueUnprotect(v.ctx.ref, v.ref)
+}
+
+// Deprecated: use ref.Protect.
 func (ctx *Context) ProtectValue(ref *Value) {
-	C.JSValueProtect(ctx.ref, ref.ref)
+	ref.Protect()
 }
 
+// Deprecated: use ref.Unprotect.
 func (ctx *Context) UnProtectValue(ref *Value) {
-	C.JSValueProtect(ctx.ref, ref.ref)
+	ref.Unprotect()
 }
@@ -3,6 +3,7 @@ package gojs
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestValue_GoValue(t *testing.T) {
@@ -22,9 +23,7 @@ func TestValue_GoValue(t *testing.T) {
 		{ctx.NewStringValue(""), ""},
 		{ctx.NewStringValue("foo"), "foo"},
 		{ctx.NewEmptyObject().ToValue(), map[string]interface{}{}},
-		// TODO(sqs): convert JavaScript Date objects to Go time.Time (currently
-		// they are converted to a Go string)
-		// {jsObjectToJSValue(ctx.NewDateWithMilliseconds(123)), time.Unix(0, 123000000).In(time.UTC)},
+		{jsObjectToJSValue(ctx.NewDateWithMilliseconds(123)), time.Unix(0, 123000000).In(time.UTC)},
 		{
 			jsObjectToJSValue(ctx.NewArray([]*Value{ctx.NewStringValue("foo")})),
 			[]interface{}{"foo"},
@@ -67,3 +66,41 @@ func TestNewValueFrom(t *testing.T) {
 		t.Errorf("want string %q, got %q", wantString, gotString)
 	}
 }
+
+func TestValueMethods(t *testing.T) {
+	ctx := NewContext()
+	defer ctx.Release()
+
+	num := ctx.NewNumberValue(42)
+	str := ctx.NewStringValue("42")
+	if num.Type() != TypeNumber || str.Type() != TypeString {
+		t.Errorf("v.Type returned %d and %d", num.Type(), str.Type())
+	}
+	if !num.IsNumber() || num.IsString() || !str.IsString() || str.IsObject() {
+		t.Errorf("v.IsX does not match the value types")
+	}
+	if !ctx.NewNullValue().IsNull() || !ctx.NewUndefinedValue().IsUndefined() || !ctx.NewBooleanValue(false).IsBoolean() {
+		t.Errorf("v.IsNull, v.IsUndefined or v.IsBoolean failed")
+	}
+	if n, err := str.ToNumber(); err != nil || n != 42 {
+		t.Errorf("str.ToNumber returned %v, %v", n, err)
+	}
+	if s, err := num.ToString(); err != nil || s != "42" {
+		t.Errorf("num.ToString returned %q, %v", s, err)
+	}
+	if eq, err := num.Equals(str); err != nil || !eq {
+		t.Errorf("num.Equals(str) returned %v, %v", eq, err)
+	}
+	if num.StrictEquals(str) {
+		t.Errorf("num.StrictEquals(str) returned true")
+	}
+	if obj, err := str.ToObject(); err != nil || !obj.ToValue().IsObject() {
+		t.Errorf("str.ToObject returned %v, %v", obj, err)
+	}
+	if _, err := ctx.NewNullValue().ToObject(); err == nil {
+		t.Errorf("null.ToObject did not return an error")
+	}
+	if num.Context() != ctx {
+		t.Errorf("v.Context did not return the context of the value")
+	}
+}